@@ -1,9 +1,12 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
-	"sync"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -14,92 +17,121 @@ var APIEndpoints = []string{
 	"https://api.hayfrp.com",
 }
 
-// BaseURL 当前使用的API端点
-var BaseURL = APIEndpoints[0]
-var currentEndpointIndex = 0
-var endpointMutex sync.Mutex
-
-// HTTPClient 公共HTTP客户端
+// HTTPClient 公共HTTP客户端，仅用于 DoRequestWithFallback
 var HTTPClient = &http.Client{
 	Timeout: 5 * time.Second,
 }
 
+// defaultPool 未指定端点的客户端共享的端点池，当前端点通过 GetCurrentEndpoint 获取
+var defaultPool = &endpointPool{
+	urls: APIEndpoints,
+}
+
 // SwitchToNextEndpoint 切换到下一个API端点
 func SwitchToNextEndpoint() bool {
-	endpointMutex.Lock()
-	defer endpointMutex.Unlock()
+	defaultPool.mu.Lock()
+	defer defaultPool.mu.Unlock()
 
-	if currentEndpointIndex < len(APIEndpoints)-1 {
-		currentEndpointIndex++
-		BaseURL = APIEndpoints[currentEndpointIndex]
-		fmt.Printf("[API] 切换到备用端点: %s\n", BaseURL)
+	if defaultPool.current < len(defaultPool.urls)-1 {
+		defaultPool.setCurrent(defaultPool.current + 1)
+		defaultLogger.Load().Info("切换到备用端点", "endpoint", defaultPool.urls[defaultPool.current])
 		return true
 	}
 	return false
 }
 
+// SetEndpoints 替换默认端点列表（按优先级排序），并重置故障转移与健康状态
+//
+// 通常在读取配置文件后调用一次，空列表会被忽略。
+func SetEndpoints(endpoints []string) {
+	var cleaned []string
+	for _, endpoint := range endpoints {
+		if endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/"); endpoint != "" {
+			cleaned = append(cleaned, endpoint)
+		}
+	}
+	if len(cleaned) == 0 {
+		return
+	}
+	APIEndpoints = cleaned
+	defaultPool.reset(APIEndpoints)
+}
+
 // ResetToPrimaryEndpoint 重置到第一个API端点
 func ResetToPrimaryEndpoint() {
-	endpointMutex.Lock()
-	defer endpointMutex.Unlock()
+	defaultPool.mu.Lock()
+	defer defaultPool.mu.Unlock()
 
-	currentEndpointIndex = 0
-	BaseURL = APIEndpoints[0]
+	defaultPool.setCurrent(0)
 }
 
-// GetCurrentEndpoint 获取当前端点
+// GetCurrentEndpoint 获取默认端点池的当前端点，可在多个协程中并发调用
 func GetCurrentEndpoint() string {
-	endpointMutex.Lock()
-	defer endpointMutex.Unlock()
-	return BaseURL
+	return defaultPool.currentURL()
 }
 
 // DoRequestWithFallback 带故障转移的请求
+//
+// 每次尝试都会基于原请求克隆出新请求并重建请求体，
+// 保证切换到备用端点时 POST 数据不会因为已被读取而丢失。
+// 请求的 Context 被取消或超时后立即返回，不再尝试其他端点。
 func DoRequestWithFallback(httpReq *http.Request) (*http.Response, error) {
-	var lastErr error
+	return defaultPool.do(HTTPClient, defaultLogger.Load(), httpReq)
+}
+
+// makeBodyReplayable 确保请求体可以被多次读取
+//
+// http.NewRequest 对 bytes.Buffer、bytes.Reader、strings.Reader 会自动设置 GetBody，
+// 其余类型的请求体在这里一次性读入内存并补上 GetBody。
+func makeBodyReplayable(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
 
-	for i := 0; i < len(APIEndpoints); i++ {
-		// 获取当前尝试的端点
-		endpointMutex.Lock()
-		tryIndex := (currentEndpointIndex + i) % len(APIEndpoints)
-		tryURL := APIEndpoints[tryIndex]
-		endpointMutex.Unlock()
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("读取请求体失败: %w", err)
+	}
 
-		// 更新请求URL
-		httpReq.URL.Scheme = "https"
-		httpReq.URL.Host = tryURL[8:] // 去掉 "https://"
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
 
-		resp, err := HTTPClient.Do(httpReq)
-		if err != nil {
-			lastErr = err
-			fmt.Printf("[API] 端点 %s 请求失败: %v\n", tryURL, err)
-			continue
-		}
+// rewriteRequest 基于原请求生成指向指定端点的新请求，并重建请求体
+//
+// rel 为已转义的、相对于端点的请求路径，会拼接在端点自身的路径之后。
+func rewriteRequest(req *http.Request, endpoint, rel string) (*http.Request, error) {
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无效的API端点 %s: %w", endpoint, err)
+	}
 
-		// 检查是否为服务器错误
-		if resp.StatusCode >= 500 {
-			resp.Body.Close()
-			lastErr = fmt.Errorf("服务器错误: %d", resp.StatusCode)
-			fmt.Printf("[API] 端点 %s 返回错误: %d\n", tryURL, resp.StatusCode)
-			continue
-		}
+	attempt := req.Clone(req.Context())
+	attempt.URL.Scheme = target.Scheme
+	attempt.URL.Host = target.Host
+	path := strings.TrimRight(target.EscapedPath(), "/") + rel
+	if attempt.URL.Path, err = url.PathUnescape(path); err != nil {
+		return nil, fmt.Errorf("无效的请求路径 %s: %w", path, err)
+	}
+	attempt.URL.RawPath = ""
+	if attempt.URL.EscapedPath() != path {
+		attempt.URL.RawPath = path
+	}
+	// 清空 Host，让请求头跟随新的端点而不是沿用原始端点
+	attempt.Host = ""
 
-		// 请求成功，更新当前端点
-		if i > 0 {
-			endpointMutex.Lock()
-			currentEndpointIndex = tryIndex
-			BaseURL = tryURL
-			endpointMutex.Unlock()
-
-			// 一段时间后尝试切回主端点
-			go func() {
-				time.Sleep(5 * time.Minute)
-				ResetToPrimaryEndpoint()
-			}()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("重建请求体失败: %w", err)
 		}
-
-		return resp, nil
+		attempt.Body = body
 	}
 
-	return nil, fmt.Errorf("所有API端点均不可用: %v", lastErr)
+	return attempt, nil
 }
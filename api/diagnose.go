@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// EndpointReport 单个API端点的诊断结果
+type EndpointReport struct {
+	URL string
+
+	// DNS 解析
+	Addrs    []string
+	DNSTime  time.Duration
+	DNSError error
+
+	// TLS 握手，仅 https 端点
+	TLSVersion  string
+	TLSTime     time.Duration
+	CertSubject string
+	CertIssuer  string
+	CertExpiry  time.Time
+	TLSError    error
+
+	// /version 接口
+	HTTPStatus   int
+	Latency      time.Duration
+	Version      *VersionInfo
+	RequestError error
+
+	// 服务器时间与本机时间之差，正数表示本机时间偏快
+	ServerTime time.Time
+	ClockSkew  time.Duration
+}
+
+// OK 端点是否完全可用
+func (r *EndpointReport) OK() bool {
+	return r.DNSError == nil && r.TLSError == nil && r.RequestError == nil
+}
+
+// DiagnoseEndpoint 依次检查端点的 DNS 解析、TLS 握手与 /version 接口
+//
+// /version 请求使用与普通请求相同的客户端配置（代理、超时、User-Agent），
+// DNS 与 TLS 检查直接连接端点。
+func DiagnoseEndpoint(ctx context.Context, endpoint string, opts ...Option) *EndpointReport {
+	report := &EndpointReport{URL: endpoint}
+
+	target, err := url.Parse(endpoint)
+	if err != nil {
+		report.DNSError = fmt.Errorf("无效的端点地址: %w", err)
+		return report
+	}
+	host := target.Hostname()
+	port := target.Port()
+	if port == "" {
+		port = "443"
+		if target.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	// DNS
+	start := time.Now()
+	report.Addrs, report.DNSError = net.DefaultResolver.LookupHost(ctx, host)
+	report.DNSTime = time.Since(start)
+
+	// TLS
+	if target.Scheme == "https" && report.DNSError == nil {
+		dialer := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: probeTimeout},
+			Config:    &tls.Config{ServerName: host},
+		}
+		start = time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		report.TLSTime = time.Since(start)
+		if err != nil {
+			report.TLSError = err
+		} else {
+			state := conn.(*tls.Conn).ConnectionState()
+			report.TLSVersion = tls.VersionName(state.Version)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				report.CertSubject = cert.Subject.CommonName
+				report.CertIssuer = cert.Issuer.CommonName
+				report.CertExpiry = cert.NotAfter
+			}
+			conn.Close()
+		}
+	}
+
+	// /version
+	// 端点池只用于本次诊断，不与其他客户端共享，也不留在 sharedPool 中
+	c := newClient(append(slices.Clone(opts), withPool(newEndpointPool([]string{endpoint})))...)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/version", nil)
+	if err != nil {
+		report.RequestError = err
+		return report
+	}
+	req.Header.Set("waf", "off")
+	req.Header.Set("User-Agent", c.userAgent)
+
+	start = time.Now()
+	resp, err := c.httpClient.Do(req)
+	report.Latency = time.Since(start)
+	if err != nil {
+		report.RequestError = err
+		return report
+	}
+	defer resp.Body.Close()
+	received := time.Now()
+
+	report.HTTPStatus = resp.StatusCode
+	if serverTime, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		report.ServerTime = serverTime
+		// 以请求往返的中点估计服务器生成响应时的本机时间
+		report.ClockSkew = received.Add(-report.Latency / 2).Sub(serverTime).Truncate(time.Second)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		report.RequestError = fmt.Errorf("读取响应失败: %w", err)
+		return report
+	}
+	if resp.StatusCode >= 400 {
+		report.RequestError = fmt.Errorf("HTTP %d", resp.StatusCode)
+		return report
+	}
+
+	var version VersionInfo
+	if err := json.Unmarshal(body, &version); err != nil {
+		report.RequestError = fmt.Errorf("解析响应失败: %w", err)
+		return report
+	}
+	report.Version = &version
+
+	return report
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProbeInterval 切换到备用端点后探测主端点的间隔
+var ProbeInterval = 30 * time.Second
+
+const (
+	// healthAlpha 健康指标的指数加权系数，越大越看重最近的请求
+	healthAlpha = 0.3
+	// unknownScore 尚无请求记录的端点得分，介于健康与故障端点之间
+	unknownScore = 1000
+	// probeSuccessThreshold 主端点连续探测成功多少次后切回
+	probeSuccessThreshold = 3
+	// probeTimeout 单次探测超时
+	probeTimeout = 5 * time.Second
+)
+
+// endpointHealth 单个端点的健康状态
+type endpointHealth struct {
+	latency   time.Duration // 成功请求的加权平均延迟
+	errorRate float64       // 加权平均错误率，0~1
+	samples   int
+	lastErr   error
+	lastCheck time.Time
+}
+
+// record 记录一次请求结果
+func (h *endpointHealth) record(latency time.Duration, err error) {
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+
+	if h.samples == 0 {
+		h.errorRate = failed
+		if err == nil {
+			h.latency = latency
+		}
+	} else {
+		h.errorRate = healthAlpha*failed + (1-healthAlpha)*h.errorRate
+		if err == nil {
+			h.latency = time.Duration(healthAlpha*float64(latency) + (1-healthAlpha)*float64(h.latency))
+		}
+	}
+
+	h.samples++
+	h.lastErr = err
+	h.lastCheck = time.Now()
+}
+
+// score 端点得分，越低越健康
+func (h *endpointHealth) score() float64 {
+	if h.samples == 0 {
+		return unknownScore
+	}
+	latency := float64(h.latency.Milliseconds() + 1)
+	if h.latency == 0 {
+		// 只失败过的端点没有延迟数据
+		latency = unknownScore
+	}
+	return latency * (1 + 10*h.errorRate)
+}
+
+// EndpointStatus 端点健康状态快照
+type EndpointStatus struct {
+	URL       string
+	Primary   bool          // 是否为主端点（列表第一项）
+	Current   bool          // 是否为当前使用的端点
+	Latency   time.Duration // 加权平均延迟
+	ErrorRate float64       // 加权平均错误率，0~1
+	Samples   int           // 已记录的请求次数
+	LastError string
+	LastCheck time.Time
+}
+
+// EndpointStatuses 获取默认端点池中各端点的健康状态
+func EndpointStatuses() []EndpointStatus {
+	return defaultPool.statuses()
+}
+
+// endpointPool 一组可互为备份的API端点及其故障转移状态
+//
+// 请求优先发往当前端点，失败后按健康得分从高到低尝试其余端点；
+// 切换到备用端点后，后台定期探测主端点，确认恢复健康后再切回。
+type endpointPool struct {
+	mu      sync.Mutex
+	urls    []string
+	health  map[string]*endpointHealth
+	current int
+	probing bool
+}
+
+// newEndpointPool 创建端点池
+func newEndpointPool(urls []string) *endpointPool {
+	return &endpointPool{urls: append([]string(nil), urls...)}
+}
+
+var (
+	poolsMu sync.Mutex
+	// pools 通过 WithEndpoints 创建的端点池，按端点列表共享
+	pools = make(map[string]*endpointPool)
+)
+
+// sharedPool 获取端点列表对应的端点池，不存在时创建
+//
+// 相同端点列表的客户端共享同一个端点池，主端点探测协程也只有一个，
+// 避免每次创建客户端（如 DiagnoseEndpoint）都留下新的后台协程。
+func sharedPool(urls []string) *endpointPool {
+	key := strings.Join(urls, "\n")
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	p, ok := pools[key]
+	if !ok {
+		p = newEndpointPool(urls)
+		pools[key] = p
+	}
+	return p
+}
+
+// reset 替换端点列表并清空健康状态
+func (p *endpointPool) reset(urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.urls = append([]string(nil), urls...)
+	p.health = nil
+	p.setCurrent(0)
+}
+
+// setCurrent 切换当前端点，调用方需持有锁
+func (p *endpointPool) setCurrent(index int) {
+	p.current = index
+}
+
+// currentURL 获取当前端点
+func (p *endpointPool) currentURL() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.urls[p.current]
+}
+
+// healthOf 获取端点的健康状态，调用方需持有锁
+func (p *endpointPool) healthOf(endpoint string) *endpointHealth {
+	if p.health == nil {
+		p.health = make(map[string]*endpointHealth)
+	}
+	h, ok := p.health[endpoint]
+	if !ok {
+		h = &endpointHealth{}
+		p.health[endpoint] = h
+	}
+	return h
+}
+
+// record 记录端点的一次请求结果
+func (p *endpointPool) record(endpoint string, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.healthOf(endpoint).record(latency, err)
+}
+
+// attemptOrder 本次请求的端点尝试顺序：当前端点优先，其余按健康得分排序
+func (p *endpointPool) attemptOrder() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	order := make([]int, 0, len(p.urls))
+	for i := range p.urls {
+		if i != p.current {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return p.healthOf(p.urls[order[a]]).score() < p.healthOf(p.urls[order[b]]).score()
+	})
+	return append([]int{p.current}, order...)
+}
+
+// relativePath 获取请求相对于端点的路径
+//
+// 请求地址通常由某个端点加上接口路径拼成，这里去掉该端点的路径前缀
+// （如 https://host/api 中的 /api），切换端点时再拼上新端点的前缀。
+// 不属于任何端点的请求原样返回其路径。
+func (p *endpointPool) relativePath(u *url.URL) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	path := u.EscapedPath()
+	best := -1
+	for _, endpoint := range p.urls {
+		e, err := url.Parse(endpoint)
+		if err != nil || e.Scheme != u.Scheme || e.Host != u.Host {
+			continue
+		}
+		prefix := strings.TrimRight(e.EscapedPath(), "/")
+		if len(prefix) > best && (path == prefix || strings.HasPrefix(path, prefix+"/")) {
+			best = len(prefix)
+		}
+	}
+	if best < 0 {
+		return path
+	}
+	return path[best:]
+}
+
+// statuses 获取各端点的健康状态快照
+func (p *endpointPool) statuses() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := make([]EndpointStatus, len(p.urls))
+	for i, endpoint := range p.urls {
+		h := p.healthOf(endpoint)
+		result[i] = EndpointStatus{
+			URL:       endpoint,
+			Primary:   i == 0,
+			Current:   i == p.current,
+			Latency:   h.latency,
+			ErrorRate: h.errorRate,
+			Samples:   h.samples,
+			LastCheck: h.lastCheck,
+		}
+		if h.lastErr != nil {
+			result[i].LastError = h.lastErr.Error()
+		}
+	}
+	return result
+}
+
+// do 使用指定的 http.Client 依次尝试端点池中的端点
+func (p *endpointPool) do(hc *http.Client, log *slog.Logger, httpReq *http.Request) (*http.Response, error) {
+	if err := makeBodyReplayable(httpReq); err != nil {
+		return nil, err
+	}
+
+	ctx := httpReq.Context()
+	rel := p.relativePath(httpReq.URL)
+	var lastErr error
+
+	for i, index := range p.attemptOrder() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		p.mu.Lock()
+		if index >= len(p.urls) {
+			// 端点列表在请求期间被替换
+			p.mu.Unlock()
+			break
+		}
+		tryURL := p.urls[index]
+		p.mu.Unlock()
+
+		attempt, err := rewriteRequest(httpReq, tryURL, rel)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		start := time.Now()
+		resp, err := hc.Do(attempt)
+		if err != nil {
+			// 调用方主动取消，不属于端点故障
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			p.record(tryURL, 0, err)
+			lastErr = err
+			log.Warn("端点请求失败", "endpoint", tryURL, "error", err)
+			continue
+		}
+
+		// 检查是否为服务器错误
+		if resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = &HTTPStatusError{StatusCode: resp.StatusCode}
+			p.record(tryURL, 0, lastErr)
+			log.Warn("端点返回错误", "endpoint", tryURL, "status", resp.StatusCode)
+			continue
+		}
+
+		p.record(tryURL, time.Since(start), nil)
+
+		// 请求成功，更新当前端点
+		if i > 0 {
+			p.mu.Lock()
+			if index < len(p.urls) && p.urls[index] == tryURL {
+				p.setCurrent(index)
+				log.Info("切换到备用端点", "endpoint", tryURL)
+				if index != 0 && !p.probing {
+					p.probing = true
+					go p.probePrimary(hc, log)
+				}
+			}
+			p.mu.Unlock()
+		}
+
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("所有API端点均不可用: %w", lastErr)
+}
+
+// probePrimary 定期探测主端点，连续多次健康后切回
+func (p *endpointPool) probePrimary(hc *http.Client, log *slog.Logger) {
+	ticker := time.NewTicker(ProbeInterval)
+	defer ticker.Stop()
+
+	successes := 0
+	for range ticker.C {
+		p.mu.Lock()
+		if p.current == 0 {
+			// 已经被手动重置或替换端点列表
+			p.probing = false
+			p.mu.Unlock()
+			return
+		}
+		primary := p.urls[0]
+		p.mu.Unlock()
+
+		start := time.Now()
+		err := probeEndpoint(hc, primary)
+		p.record(primary, time.Since(start), err)
+		log.Debug("探测主端点", "endpoint", primary, "error", err)
+		if err != nil {
+			successes = 0
+			continue
+		}
+
+		successes++
+		if successes < probeSuccessThreshold {
+			continue
+		}
+
+		p.mu.Lock()
+		if len(p.urls) > 0 && p.urls[0] == primary {
+			p.setCurrent(0)
+			log.Info("主端点已恢复，切回", "endpoint", primary)
+		}
+		p.probing = false
+		p.mu.Unlock()
+		return
+	}
+}
+
+// probeEndpoint 请求端点的 /version 接口检查其是否可用
+func probeEndpoint(hc *http.Client, endpoint string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/version", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("waf", "off")
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("服务器错误: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPoolKeepsEndpointPathPrefix(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	var gotPath string
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+	}))
+	defer up.Close()
+
+	pool := newEndpointPool([]string{down.URL + "/v1", up.URL + "/api"})
+	req, err := http.NewRequest(http.MethodGet, down.URL+"/v1/user/info%2Fx", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := pool.do(http.DefaultClient, defaultLogger.Load(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want := "/api/user/info%2Fx"; gotPath != want {
+		t.Errorf("备用端点收到的路径为 %q，期望 %q", gotPath, want)
+	}
+
+	pool.mu.Lock()
+	pool.current = 0 // 让探测协程退出
+	pool.mu.Unlock()
+}
+
+func TestRelativePath(t *testing.T) {
+	pool := newEndpointPool([]string{"https://a.example.com/api", "https://a.example.com", "https://b.example.com/"})
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://a.example.com/api/version", "/version"},
+		{"https://a.example.com/apix/version", "/apix/version"},
+		{"https://b.example.com/version", "/version"},
+		{"https://c.example.com/x/version", "/x/version"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		if got := pool.relativePath(req.URL); got != tt.want {
+			t.Errorf("relativePath(%s) = %q，期望 %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSharedPool(t *testing.T) {
+	a := sharedPool([]string{"https://a.example.com", "https://b.example.com"})
+	b := sharedPool([]string{"https://a.example.com", "https://b.example.com"})
+	c := sharedPool([]string{"https://b.example.com", "https://a.example.com"})
+	if a != b {
+		t.Error("相同端点列表应共享端点池")
+	}
+	if a == c {
+		t.Error("不同端点列表不应共享端点池")
+	}
+}
+
+func TestDiagnoseEndpointIsolated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ver_hayfrps":"1.0"}`))
+	}))
+	defer srv.Close()
+
+	opts := make([]Option, 1, 4)
+	opts[0] = WithUserAgent("test")
+	report := DiagnoseEndpoint(context.Background(), srv.URL, opts...)
+	if report.RequestError != nil {
+		t.Fatal(report.RequestError)
+	}
+
+	if opts[:cap(opts)][1] != nil {
+		t.Error("DiagnoseEndpoint 不应写入调用方 opts 的底层数组")
+	}
+	poolsMu.Lock()
+	_, cached := pools[srv.URL]
+	poolsMu.Unlock()
+	if cached {
+		t.Error("诊断使用的端点池不应被缓存")
+	}
+}
+
+func TestCurrentEndpointConcurrent(t *testing.T) {
+	// 与 -race 一起运行，检查切换端点与读取当前端点之间没有数据竞争
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			SwitchToNextEndpoint()
+			ResetToPrimaryEndpoint()
+		}
+	}()
+	for range 100 {
+		GetCurrentEndpoint()
+	}
+	<-done
+}
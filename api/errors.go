@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// API 业务错误分类，可配合 errors.Is 判断
+var (
+	// ErrTokenExpired Token（csrf）无效或已过期 (403)
+	ErrTokenExpired = errors.New("登录无效或已过期")
+	// ErrForbidden 未携带 csrf 的请求被拒绝 (403)，如密码错误、用户名已存在、操作频繁
+	ErrForbidden = errors.New("请求被拒绝")
+	// ErrMissingField 数据缺失或参数错误 (404)
+	ErrMissingField = errors.New("数据缺失")
+	// ErrServer 服务器异常 (500)
+	ErrServer = errors.New("服务器异常")
+	// ErrTunnelOffline 隧道离线 (500)
+	ErrTunnelOffline = errors.New("隧道离线")
+	// ErrNodeOffline 隧道对应节点离线 (500)
+	ErrNodeOffline = errors.New("节点离线")
+	// ErrAccountDisabled 账号已被封禁 (用户信息接口 status 为 false)
+	ErrAccountDisabled = errors.New("账号已被封禁")
+	// ErrRefreshFailed Token 过期后 WithTokenRefresher 刷新失败，与 ErrTokenExpired 及刷新错误一同返回
+	ErrRefreshFailed = errors.New("刷新Token失败")
+)
+
+// APIError API返回的业务错误，可通过 errors.As 获取状态码与原始提示信息
+type APIError struct {
+	Status  int    // 响应中的状态码，账号封禁时为 0
+	Message string // 服务器返回的提示信息
+	Kind    error  // 错误分类，如 ErrTokenExpired
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Kind != nil {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("API错误 (状态码 %d)", e.Status)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// HTTPStatusError 服务器返回了非预期的 HTTP 状态码，且响应中没有可解析的业务状态
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.StatusCode >= 500 {
+		return fmt.Sprintf("服务器错误: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("请求失败: HTTP %d", e.StatusCode)
+}
+
+// classifyStatus 根据状态码和提示信息生成错误，成功时返回 nil
+//
+// authed 表示请求是否携带 csrf：只有携带 csrf 的请求返回 403 才意味着登录过期，
+// 登录、注册等接口的 403 表示密码错误、用户已存在或操作频繁。
+func classifyStatus(status int, message string, authed bool) error {
+	if status == 200 {
+		return nil
+	}
+
+	e := &APIError{Status: status, Message: message}
+	switch status {
+	case 403:
+		if authed {
+			e.Kind = ErrTokenExpired
+		} else {
+			e.Kind = ErrForbidden
+		}
+	case 404:
+		e.Kind = ErrMissingField
+	case 500:
+		switch {
+		case strings.Contains(message, "节点") && strings.Contains(message, "离线"):
+			e.Kind = ErrNodeOffline
+		case strings.Contains(message, "离线"):
+			e.Kind = ErrTunnelOffline
+		default:
+			e.Kind = ErrServer
+		}
+	}
+	return e
+}
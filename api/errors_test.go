@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		authed  bool
+		want    error
+	}{
+		{403, "登录失效", true, ErrTokenExpired},
+		{403, "密码错误", false, ErrForbidden},
+		{404, "缺少参数", true, ErrMissingField},
+		{500, "节点离线", true, ErrNodeOffline},
+		{500, "隧道已离线", true, ErrTunnelOffline},
+		{500, "数据库错误", true, ErrServer},
+	}
+	for _, tt := range tests {
+		err := classifyStatus(tt.status, tt.message, tt.authed)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("classifyStatus(%d, %q, %v) = %v，期望 %v", tt.status, tt.message, tt.authed, err, tt.want)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Status != tt.status || apiErr.Message != tt.message {
+			t.Errorf("classifyStatus(%d, %q) 返回的 APIError 不完整: %#v", tt.status, tt.message, err)
+		}
+	}
+
+	if err := classifyStatus(200, "ok", true); err != nil {
+		t.Errorf("classifyStatus(200) = %v，期望 nil", err)
+	}
+
+	// 未知状态码没有分类，但仍然是 APIError
+	err := classifyStatus(418, "", false)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Kind != nil {
+		t.Errorf("classifyStatus(418) = %#v", err)
+	}
+	if err.Error() != "API错误 (状态码 418)" {
+		t.Errorf("classifyStatus(418).Error() = %q", err.Error())
+	}
+}
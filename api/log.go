@@ -0,0 +1,71 @@
+package api
+
+import (
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+)
+
+// traceBodyLimit 请求跟踪日志中记录的最大请求/响应体长度
+const traceBodyLimit = 4096
+
+// defaultLogger api 包的默认日志记录器，默认不输出任何内容
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	defaultLogger.Store(slog.New(slog.DiscardHandler))
+}
+
+// SetLogger 设置 api 包的默认日志记录器，传入 nil 关闭日志
+//
+// 未通过 WithLogger 指定日志记录器的客户端以及端点故障转移、探测都使用它。
+func SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	defaultLogger.Store(logger)
+}
+
+// WithLogger 为客户端指定日志记录器
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithTrace 在 Debug 级别记录完整的请求与响应内容，csrf、密码等敏感字段会被遮盖
+func WithTrace(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.trace = enabled
+	}
+}
+
+// logger 获取客户端使用的日志记录器
+func (c *Client) logger() *slog.Logger {
+	if c.log != nil {
+		return c.log
+	}
+	return defaultLogger.Load()
+}
+
+// 敏感字段的匹配规则
+var (
+	// JSON 字段，如 "csrf":"xxx"
+	redactJSON = regexp.MustCompile(`("(?:csrf|passwd|newpass|token)"\s*:\s*)"[^"]*"`)
+	// 表单字段，如 csrf=xxx
+	redactForm = regexp.MustCompile(`((?:^|&)(?:csrf|passwd|newpass|token)=)[^&]*`)
+	// frpc 配置中的认证信息，如 user = "xxx"、token = xxx
+	redactConfig = regexp.MustCompile(`(?m)^(\s*(?:user|token|meta_token|auth\.token)\s*=\s*).+$`)
+)
+
+// redact 遮盖请求/响应体中的敏感字段并截断过长的内容
+func redact(body []byte) string {
+	s := string(body)
+	s = redactJSON.ReplaceAllString(s, `$1"***"`)
+	s = redactForm.ReplaceAllString(s, `${1}***`)
+	s = redactConfig.ReplaceAllString(s, `${1}***`)
+	if len(s) > traceBodyLimit {
+		s = s[:traceBodyLimit] + "...(已截断)"
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"json", `{"type":"login","user":"a","passwd":"secret","csrf" : "abc"}`, `{"type":"login","user":"a","passwd":"***","csrf" : "***"}`},
+		{"json token", `{"status":200,"token":"t0k3n"}`, `{"status":200,"token":"***"}`},
+		{"form", `type=down&csrf=abc&id=1`, `type=down&csrf=***&id=1`},
+		{"form leading", `csrf=abc&id=1`, `csrf=***&id=1`},
+		{"config", "[common]\nserver_addr = a.com\nuser = abc\n  token = xyz\nmeta_token=1\n", "[common]\nserver_addr = a.com\nuser = ***\n  token = ***\nmeta_token=***\n"},
+		{"other fields", `{"username":"a","tokens":1}`, `{"username":"a","tokens":1}`},
+	}
+	for _, tt := range tests {
+		if got := redact([]byte(tt.in)); got != tt.want {
+			t.Errorf("%s: redact(%q) = %q，期望 %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactTruncates(t *testing.T) {
+	got := redact([]byte(strings.Repeat("a", traceBodyLimit+10)))
+	if !strings.HasSuffix(got, "...(已截断)") || len(got) != traceBodyLimit+len("...(已截断)") {
+		t.Errorf("超长内容未按 %d 字节截断，长度 %d", traceBodyLimit, len(got))
+	}
+}
@@ -1,49 +1,44 @@
 package api
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"net/http"
-	"time"
 )
 
 // NodeAPIClient 节点相关API客户端
 type NodeAPIClient struct {
-	client *http.Client
+	client *Client
 }
 
 // NewNodeAPIClient 创建节点API客户端
-func NewNodeAPIClient() *NodeAPIClient {
+func NewNodeAPIClient(opts ...Option) *NodeAPIClient {
 	return &NodeAPIClient{
-		client: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		client: newClient(opts...),
 	}
 }
 
 // NodeInfo 节点探针信息
 type NodeInfo struct {
-	ID              string `json:"id"`
-	Name            string `json:"name"`
-	Version         string `json:"version"`
-	BindPort        string `json:"bind_port"`
-	BindUDPPort     string `json:"bind_udp_port"`
-	VhostHTTPPort   string `json:"vhost_http_port"`
-	VhostHTTPSPort  string `json:"vhost_https_port"`
-	KCPBindPort     string `json:"kcp_bind_port"`
-	SubdomainHost   string `json:"subdomain_host"`
-	MaxPoolCount    string `json:"max_pool_count"`
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	BindPort          string `json:"bind_port"`
+	BindUDPPort       string `json:"bind_udp_port"`
+	VhostHTTPPort     string `json:"vhost_http_port"`
+	VhostHTTPSPort    string `json:"vhost_https_port"`
+	KCPBindPort       string `json:"kcp_bind_port"`
+	SubdomainHost     string `json:"subdomain_host"`
+	MaxPoolCount      string `json:"max_pool_count"`
 	MaxPortsPerClient string `json:"max_ports_per_client"`
-	HeartBeatTimeout string `json:"heart_beat_timeout"`
-	TotalTrafficIn  string `json:"total_traffic_in"`
-	TotalTrafficOut string `json:"total_traffic_out"`
-	CurConns        string `json:"cur_conns"`
-	ClientCounts    string `json:"client_counts"`
-	CPUUsage        string `json:"cpu_usage"`
-	RAMUsage        string `json:"ram_usage"`
-	DiskUsage       string `json:"disk_usage"`
-	Status          string `json:"status"`
+	HeartBeatTimeout  string `json:"heart_beat_timeout"`
+	TotalTrafficIn    string `json:"total_traffic_in"`
+	TotalTrafficOut   string `json:"total_traffic_out"`
+	CurConns          string `json:"cur_conns"`
+	ClientCounts      string `json:"client_counts"`
+	CPUUsage          string `json:"cpu_usage"`
+	RAMUsage          string `json:"ram_usage"`
+	DiskUsage         string `json:"disk_usage"`
+	Status            string `json:"status"`
 }
 
 // GetNodeInfoResponse 获取节点探针信息响应
@@ -56,28 +51,12 @@ type GetNodeInfoResponse struct {
 
 // GetNodeInfo 获取节点探针信息
 func (c *NodeAPIClient) GetNodeInfo() (*GetNodeInfoResponse, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/node", nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result GetNodeInfoResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.GetNodeInfoContext(context.Background())
+}
 
-	return &result, nil
+// GetNodeInfoContext 获取节点探针信息，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetNodeInfoContext(ctx context.Context) (*GetNodeInfoResponse, error) {
+	return get[GetNodeInfoResponse](ctx, c.client, "/node")
 }
 
 // NodeListItem 节点列表项
@@ -97,103 +76,46 @@ type GetNodeListResponse struct {
 
 // GetNodeList 获取节点列表
 func (c *NodeAPIClient) GetNodeList() (*GetNodeListResponse, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/nodes", nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result GetNodeListResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.GetNodeListContext(context.Background())
+}
 
-	return &result, nil
+// GetNodeListContext 获取节点列表，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetNodeListContext(ctx context.Context) (*GetNodeListResponse, error) {
+	return get[GetNodeListResponse](ctx, c.client, "/nodes")
 }
 
 // GetNotice 获取公告
 func (c *NodeAPIClient) GetNotice() (string, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/notice", nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	// 检查是否返回JSON错误
-	if len(respBody) > 0 && respBody[0] == '{' {
-		var result struct {
-			Status  int    `json:"status"`
-			Message string `json:"message"`
-		}
-		if err := json.Unmarshal(respBody, &result); err != nil {
-			return "", fmt.Errorf("解析响应失败: %w", err)
-		}
-		if result.Status != 200 {
-			return "", fmt.Errorf("%s", result.Message)
-		}
-	}
+	return c.GetNoticeContext(context.Background())
+}
 
-	return string(respBody), nil
+// GetNoticeContext 获取公告，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetNoticeContext(ctx context.Context) (string, error) {
+	return c.client.text(ctx, apiRequest{method: http.MethodGet, path: "/notice", idempotent: true})
 }
 
 // HayFrpInfo HayFrp服务统计
 type HayFrpInfo struct {
-	Status    int     `json:"status"`
-	Aflow     string  `json:"aflow"`
-	Aflowin   string  `json:"aflowin"`
-	Aflowout  string  `json:"aflowout"`
-	Eflow     string  `json:"eflow"`
-	Eflowin   string  `json:"eflowin"`
-	Eflowout  string  `json:"eflowout"`
-	Oclient   int     `json:"oclient"`
-	Totalrun  string  `json:"totalrun"`
-	Todayrun  string  `json:"todayrun"`
+	Status   int    `json:"status"`
+	Aflow    string `json:"aflow"`
+	Aflowin  string `json:"aflowin"`
+	Aflowout string `json:"aflowout"`
+	Eflow    string `json:"eflow"`
+	Eflowin  string `json:"eflowin"`
+	Eflowout string `json:"eflowout"`
+	Oclient  int    `json:"oclient"`
+	Totalrun string `json:"totalrun"`
+	Todayrun string `json:"todayrun"`
 }
 
 // GetHayFrpInfo 获取HayFrp服务统计
 func (c *NodeAPIClient) GetHayFrpInfo() (*HayFrpInfo, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/info", nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result HayFrpInfo
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.GetHayFrpInfoContext(context.Background())
+}
 
-	return &result, nil
+// GetHayFrpInfoContext 获取HayFrp服务统计，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetHayFrpInfoContext(ctx context.Context) (*HayFrpInfo, error) {
+	return get[HayFrpInfo](ctx, c.client, "/info")
 }
 
 // DownloadListItem 下载列表项
@@ -220,36 +142,20 @@ type DownloadLists struct {
 
 // DownloadListResponse 下载列表响应
 type DownloadListResponse struct {
-	Status  int            `json:"status"`
-	Message string         `json:"message"`
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
 	Sources []DownloadSource `json:"sources"`
-	Lists   DownloadLists  `json:"lists"`
+	Lists   DownloadLists    `json:"lists"`
 }
 
 // GetDownloadList 获取下载列表
 func (c *NodeAPIClient) GetDownloadList() (*DownloadListResponse, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/downlist", nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result DownloadListResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.GetDownloadListContext(context.Background())
+}
 
-	return &result, nil
+// GetDownloadListContext 获取下载列表，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetDownloadListContext(ctx context.Context) (*DownloadListResponse, error) {
+	return get[DownloadListResponse](ctx, c.client, "/downlist")
 }
 
 // VersionInfo 版本信息
@@ -264,26 +170,10 @@ type VersionInfo struct {
 
 // GetVersion 获取版本信息
 func (c *NodeAPIClient) GetVersion() (*VersionInfo, error) {
-	httpReq, err := http.NewRequest("GET", BaseURL+"/version", nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result VersionInfo
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.GetVersionContext(context.Background())
+}
 
-	return &result, nil
+// GetVersionContext 获取版本信息，可通过 ctx 取消请求或设置超时
+func (c *NodeAPIClient) GetVersionContext(ctx context.Context) (*VersionInfo, error) {
+	return get[VersionInfo](ctx, c.client, "/version")
 }
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout 客户端默认请求超时
+const DefaultTimeout = 15 * time.Second
+
+// DefaultUserAgent 默认 User-Agent
+const DefaultUserAgent = "HayFrp-Cli"
+
+// Option 客户端配置项，用于 NewUserAPIClient、NewProxyAPIClient、NewNodeAPIClient
+type Option func(*clientOptions)
+
+// clientOptions 创建客户端时收集的配置
+type clientOptions struct {
+	httpClient *http.Client
+	transport  http.RoundTripper
+	timeout    time.Duration
+	endpoints  []string
+	userAgent  string
+	retry      *RetryPolicy
+	logger     *slog.Logger
+	trace      bool
+	refresher  TokenRefresher
+
+	// pool 不为 nil 时直接使用该端点池，不经过 sharedPool 共享与缓存
+	pool *endpointPool
+}
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = hc
+	}
+}
+
+// WithTransport 使用自定义的 RoundTripper，例如走企业出口代理或指向测试桩
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *clientOptions) {
+		o.transport = rt
+	}
+}
+
+// WithTimeout 设置单次请求超时
+func WithTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = d
+	}
+}
+
+// WithEndpoints 使用独立的端点列表（按优先级排序），只与使用相同端点列表的客户端共享故障转移状态
+func WithEndpoints(endpoints ...string) Option {
+	return func(o *clientOptions) {
+		o.endpoints = endpoints
+	}
+}
+
+// withPool 使用独立的端点池，用于诊断等只针对单个端点的一次性请求
+func withPool(p *endpointPool) Option {
+	return func(o *clientOptions) {
+		o.pool = p
+	}
+}
+
+// WithUserAgent 设置请求的 User-Agent
+func WithUserAgent(ua string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = ua
+	}
+}
@@ -1,45 +1,39 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
+	"context"
+	"net/url"
 )
 
 // ProxyAPIClient 隧道相关API客户端
 type ProxyAPIClient struct {
-	client *http.Client
+	client *Client
 }
 
 // NewProxyAPIClient 创建隧道API客户端
-func NewProxyAPIClient() *ProxyAPIClient {
+func NewProxyAPIClient(opts ...Option) *ProxyAPIClient {
 	return &ProxyAPIClient{
-		client: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		client: newClient(opts...),
 	}
 }
 
 // AddTunnelRequest 添加隧道请求
 type AddTunnelRequest struct {
-	Type                 string `json:"type"`
-	Csrf                 string `json:"csrf"`
-	ProxyName            string `json:"proxy_name"`
-	ProxyType            string `json:"proxy_type"`
-	LocalIP              string `json:"local_ip"`
-	LocalPort            int    `json:"local_port"`
-	RemotePort           int    `json:"remote_port"`
-	UseEncryption        string `json:"use_encryption"`
-	UseCompression       string `json:"use_compression"`
-	SK                   string `json:"sk"`
-	Node                 string `json:"node"`
-	Domain               string `json:"domain"`
-	Locations            string `json:"locations"`
-	HeaderXFromWhere     string `json:"header_X_From_Where"`
-	HostHeaderRewrite    string `json:"host_header_rewrite"`
+	Type              string `json:"type"`
+	Csrf              string `json:"csrf"`
+	ProxyName         string `json:"proxy_name"`
+	ProxyType         string `json:"proxy_type"`
+	LocalIP           string `json:"local_ip"`
+	LocalPort         int    `json:"local_port"`
+	RemotePort        int    `json:"remote_port"`
+	UseEncryption     string `json:"use_encryption"`
+	UseCompression    string `json:"use_compression"`
+	SK                string `json:"sk"`
+	Node              string `json:"node"`
+	Domain            string `json:"domain"`
+	Locations         string `json:"locations"`
+	HeaderXFromWhere  string `json:"header_X_From_Where"`
+	HostHeaderRewrite string `json:"host_header_rewrite"`
 }
 
 // AddTunnelResponse 添加隧道响应
@@ -51,55 +45,40 @@ type AddTunnelResponse struct {
 
 // AddTunnel 添加隧道
 func (c *ProxyAPIClient) AddTunnel(req *AddTunnelRequest) (*AddTunnelResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result AddTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.AddTunnelContext(context.Background(), req)
+}
 
-	return &result, nil
+// AddTunnelContext 添加隧道，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) AddTunnelContext(ctx context.Context, req *AddTunnelRequest) (*AddTunnelResponse, error) {
+	return call[*AddTunnelRequest, AddTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		authed: true,
+		csrf:   req.Csrf,
+		retoken: func(csrf string) any {
+			copied := *req
+			copied.Csrf = csrf
+			return &copied
+		},
+	})
 }
 
 // EditTunnelRequest 编辑隧道请求
 type EditTunnelRequest struct {
-	Type                 string `json:"type"`
-	Csrf                 string `json:"csrf"`
-	ID                   string `json:"id"`
-	ProxyName            string `json:"proxy_name"`
-	ProxyType            string `json:"proxy_type"`
-	LocalIP              string `json:"local_ip"`
-	LocalPort            int    `json:"local_port"`
-	RemotePort           int    `json:"remote_port"`
-	UseEncryption        string `json:"use_encryption"`
-	UseCompression       string `json:"use_compression"`
-	SK                   string `json:"sk"`
-	Node                 string `json:"node"`
-	Domain               string `json:"domain"`
-	Locations            string `json:"locations"`
-	HeaderXFromWhere     string `json:"header_X_From_Where"`
-	HostHeaderRewrite    string `json:"host_header_rewrite"`
+	Type              string `json:"type"`
+	Csrf              string `json:"csrf"`
+	ID                string `json:"id"`
+	ProxyName         string `json:"proxy_name"`
+	ProxyType         string `json:"proxy_type"`
+	LocalIP           string `json:"local_ip"`
+	LocalPort         int    `json:"local_port"`
+	RemotePort        int    `json:"remote_port"`
+	UseEncryption     string `json:"use_encryption"`
+	UseCompression    string `json:"use_compression"`
+	SK                string `json:"sk"`
+	Node              string `json:"node"`
+	Domain            string `json:"domain"`
+	Locations         string `json:"locations"`
+	HeaderXFromWhere  string `json:"header_X_From_Where"`
+	HostHeaderRewrite string `json:"host_header_rewrite"`
 }
 
 // EditTunnelResponse 编辑隧道响应
@@ -110,35 +89,20 @@ type EditTunnelResponse struct {
 
 // EditTunnel 编辑隧道
 func (c *ProxyAPIClient) EditTunnel(req *EditTunnelRequest) (*EditTunnelResponse, error) {
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result EditTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+	return c.EditTunnelContext(context.Background(), req)
+}
 
-	return &result, nil
+// EditTunnelContext 编辑隧道，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) EditTunnelContext(ctx context.Context, req *EditTunnelRequest) (*EditTunnelResponse, error) {
+	return call[*EditTunnelRequest, EditTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		authed: true,
+		csrf:   req.Csrf,
+		retoken: func(csrf string) any {
+			copied := *req
+			copied.Csrf = csrf
+			return &copied
+		},
+	})
 }
 
 // DeleteTunnelRequest 删除隧道请求
@@ -156,40 +120,24 @@ type DeleteTunnelResponse struct {
 
 // DeleteTunnel 删除隧道
 func (c *ProxyAPIClient) DeleteTunnel(csrf, id string) (*DeleteTunnelResponse, error) {
+	return c.DeleteTunnelContext(context.Background(), csrf, id)
+}
+
+// DeleteTunnelContext 删除隧道，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) DeleteTunnelContext(ctx context.Context, csrf, id string) (*DeleteTunnelResponse, error) {
 	req := DeleteTunnelRequest{
 		Type: "remove",
 		Csrf: csrf,
 		ID:   id,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result DeleteTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[DeleteTunnelRequest, DeleteTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		authed: true,
+		csrf:   req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // ListTunnelRequest 列出隧道请求
@@ -201,26 +149,26 @@ type ListTunnelRequest struct {
 
 // TunnelInfo 隧道信息
 type TunnelInfo struct {
-	ID                 string `json:"id"`
-	UUID               string `json:"uuid"`
-	Username           string `json:"username"`
-	ProxyName          string `json:"proxy_name"`
-	ProxyType          string `json:"proxy_type"`
-	LocalIP            string `json:"local_ip"`
-	LocalPort          string `json:"local_port"`
-	UseEncryption      string `json:"use_encryption"`
-	UseCompression     string `json:"use_compression"`
-	Domain             string `json:"domain"`
-	Locations          string `json:"locations"`
-	HostHeaderRewrite  string `json:"host_header_rewrite"`
-	RemotePort         string `json:"remote_port"`
-	SK                 string `json:"sk"`
-	HeaderXFromWhere   string `json:"header_X-From-Where"`
-	Status             string `json:"status"`
-	LastUpdate         string `json:"lastupdate"`
-	Node               string `json:"node"`
-	NodeName           string `json:"node_name"`
-	NodeDomain         string `json:"node_domain"`
+	ID                string `json:"id"`
+	UUID              string `json:"uuid"`
+	Username          string `json:"username"`
+	ProxyName         string `json:"proxy_name"`
+	ProxyType         string `json:"proxy_type"`
+	LocalIP           string `json:"local_ip"`
+	LocalPort         string `json:"local_port"`
+	UseEncryption     string `json:"use_encryption"`
+	UseCompression    string `json:"use_compression"`
+	Domain            string `json:"domain"`
+	Locations         string `json:"locations"`
+	HostHeaderRewrite string `json:"host_header_rewrite"`
+	RemotePort        string `json:"remote_port"`
+	SK                string `json:"sk"`
+	HeaderXFromWhere  string `json:"header_X-From-Where"`
+	Status            string `json:"status"`
+	LastUpdate        string `json:"lastupdate"`
+	Node              string `json:"node"`
+	NodeName          string `json:"node_name"`
+	NodeDomain        string `json:"node_domain"`
 }
 
 // ListTunnelResponse 列出隧道响应
@@ -232,40 +180,25 @@ type ListTunnelResponse struct {
 
 // ListTunnel 列出隧道
 func (c *ProxyAPIClient) ListTunnel(csrf, id string) (*ListTunnelResponse, error) {
+	return c.ListTunnelContext(context.Background(), csrf, id)
+}
+
+// ListTunnelContext 列出隧道，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) ListTunnelContext(ctx context.Context, csrf, id string) (*ListTunnelResponse, error) {
 	req := ListTunnelRequest{
 		Type: "list",
 		Csrf: csrf,
 		ID:   id,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result ListTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[ListTunnelRequest, ListTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		idempotent: true,
+		authed:     true,
+		csrf:       req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // TunnelConfigRequest 获取隧道配置请求
@@ -279,6 +212,11 @@ type TunnelConfigRequest struct {
 
 // GetTunnelConfig 获取隧道配置文件
 func (c *ProxyAPIClient) GetTunnelConfig(format, csrf, node, proxy string) (string, error) {
+	return c.GetTunnelConfigContext(context.Background(), format, csrf, node, proxy)
+}
+
+// GetTunnelConfigContext 获取隧道配置文件，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) GetTunnelConfigContext(ctx context.Context, format, csrf, node, proxy string) (string, error) {
 	req := TunnelConfigRequest{
 		Type:   "config",
 		Format: format,
@@ -286,44 +224,15 @@ func (c *ProxyAPIClient) GetTunnelConfig(format, csrf, node, proxy string) (stri
 		Node:   node,
 		Proxy:  proxy,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	// 检查是否返回JSON错误
-	if len(respBody) > 0 && respBody[0] == '{' {
-		var result struct {
-			Status  int    `json:"status"`
-			Message string `json:"message"`
-		}
-		if err := json.Unmarshal(respBody, &result); err != nil {
-			return "", fmt.Errorf("解析响应失败: %w", err)
-		}
-		if result.Status != 200 {
-			return "", fmt.Errorf("%s", result.Message)
-		}
-	}
-
-	return string(respBody), nil
+	return callText(ctx, c.client, "/proxy", req, callSpec{
+		idempotent: true,
+		authed:     true,
+		csrf:       req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // ToggleTunnelRequest 切换隧道状态请求
@@ -342,41 +251,25 @@ type ToggleTunnelResponse struct {
 
 // ToggleTunnel 切换隧道状态
 func (c *ProxyAPIClient) ToggleTunnel(csrf, id, toggle string) (*ToggleTunnelResponse, error) {
+	return c.ToggleTunnelContext(context.Background(), csrf, id, toggle)
+}
+
+// ToggleTunnelContext 切换隧道状态，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) ToggleTunnelContext(ctx context.Context, csrf, id, toggle string) (*ToggleTunnelResponse, error) {
 	req := ToggleTunnelRequest{
 		Type:   "toggle",
 		Csrf:   csrf,
 		ID:     id,
 		Toggle: toggle,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result ToggleTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[ToggleTunnelRequest, ToggleTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		authed: true,
+		csrf:   req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // CheckTunnelRequest 检查隧道状态请求
@@ -395,40 +288,25 @@ type CheckTunnelResponse struct {
 
 // CheckTunnel 检查隧道状态
 func (c *ProxyAPIClient) CheckTunnel(csrf, id string) (*CheckTunnelResponse, error) {
+	return c.CheckTunnelContext(context.Background(), csrf, id)
+}
+
+// CheckTunnelContext 检查隧道状态，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) CheckTunnelContext(ctx context.Context, csrf, id string) (*CheckTunnelResponse, error) {
 	req := CheckTunnelRequest{
 		Type: "check",
 		Csrf: csrf,
 		ID:   id,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result CheckTunnelResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[CheckTunnelRequest, CheckTunnelResponse](ctx, c.client, "/proxy", req, callSpec{
+		idempotent: true,
+		authed:     true,
+		csrf:       req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // ForceDownRequest 强制下线隧道请求
@@ -446,31 +324,16 @@ type ForceDownResponse struct {
 
 // ForceDown 强制下线隧道
 func (c *ProxyAPIClient) ForceDown(csrf, id string) (*ForceDownResponse, error) {
-	// 使用 form-urlencoded 格式，与参考代码一致
-	formData := fmt.Sprintf("type=forcedown&csrf=%s&id=%s", csrf, id)
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/proxy", bytes.NewBufferString(formData))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
+	return c.ForceDownContext(context.Background(), csrf, id)
+}
 
-	var result ForceDownResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
+// ForceDownContext 强制下线隧道，可通过 ctx 取消请求或设置超时
+func (c *ProxyAPIClient) ForceDownContext(ctx context.Context, csrf, id string) (*ForceDownResponse, error) {
+	// 使用 form-urlencoded 格式，与参考代码一致
+	form := url.Values{}
+	form.Set("type", "forcedown")
+	form.Set("csrf", csrf)
+	form.Set("id", id)
 
-	return &result, nil
+	return callForm[ForceDownResponse](ctx, c.client, "/proxy", form)
 }
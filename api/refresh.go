@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// TokenRefresher 在 csrf 过期时获取新的 csrf
+//
+// expired 为过期请求所携带的 csrf，返回的 csrf 会替换原请求中的值并重放一次。
+// 返回错误时请求返回同时包含 ErrTokenExpired、ErrRefreshFailed 与该错误的错误，
+// 调用方可据此区分网络故障等临时错误与无法刷新的情况。
+type TokenRefresher func(ctx context.Context, expired string) (string, error)
+
+// WithTokenRefresher 在携带 csrf 的请求返回 403 时调用 refresher 刷新 Token 并重放请求
+//
+// 验证 Token 的请求（VerifyCsrf）不会触发刷新，以便如实反映 Token 状态。
+// refresher 内部发起的请求应使用未设置该选项的客户端，避免递归。
+func WithTokenRefresher(refresher TokenRefresher) Option {
+	return func(o *clientOptions) {
+		o.refresher = refresher
+	}
+}
+
+// tokenRefresh 同一客户端上的 Token 刷新状态
+//
+// 并发请求同时遇到同一个过期 Token 时只刷新一次，其余请求直接使用刷新结果。
+type tokenRefresh struct {
+	mu        sync.Mutex
+	refresher TokenRefresher
+	refreshed map[string]string // 过期 Token -> 新 Token
+}
+
+// refresh 返回 expired 对应的新 Token
+func (t *tokenRefresh) refresh(ctx context.Context, expired string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if fresh, ok := t.refreshed[expired]; ok {
+		return fresh, nil
+	}
+	fresh, err := t.refresher(ctx, expired)
+	if err != nil {
+		return "", err
+	}
+	if fresh == "" {
+		return "", errors.New("刷新后的Token为空")
+	}
+	if t.refreshed == nil {
+		t.refreshed = make(map[string]string)
+	}
+	t.refreshed[expired] = fresh
+	return fresh, nil
+}
+
+// exchange 发送请求并检查业务状态码，Token 过期时按 WithTokenRefresher 刷新后重放一次
+//
+// requireJSON 为 false 时允许返回非 JSON 的文本响应（如配置文件）。
+func (c *Client) exchange(ctx context.Context, r apiRequest, requireJSON bool) ([]byte, error) {
+	respBody, err := c.exchangeOnce(ctx, r, requireJSON)
+	if !errors.Is(err, ErrTokenExpired) || c.refresh == nil || r.rebuild == nil {
+		return respBody, err
+	}
+
+	log := c.logger()
+	log.Info("Token已过期，尝试刷新", "path", r.path)
+	fresh, rerr := c.refresh.refresh(ctx, r.csrf)
+	if rerr != nil {
+		log.Warn("刷新Token失败", "path", r.path, "error", rerr)
+		return nil, fmt.Errorf("%w (%w: %w)", err, ErrRefreshFailed, rerr)
+	}
+
+	body, rerr := r.rebuild(fresh)
+	if rerr != nil {
+		return nil, fmt.Errorf("%w (%w: %w)", err, ErrRefreshFailed, rerr)
+	}
+	r.body = body
+	r.csrf = fresh
+	log.Info("Token已刷新，重放请求", "path", r.path)
+	return c.exchangeOnce(ctx, r, requireJSON)
+}
+
+// exchangeOnce 发送请求并检查业务状态码
+func (c *Client) exchangeOnce(ctx context.Context, r apiRequest, requireJSON bool) ([]byte, error) {
+	respBody, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if requireJSON || isJSONObject(respBody) {
+		if err := checkStatus(respBody, r.authed); err != nil {
+			return nil, r.markExpired(err)
+		}
+	}
+	return respBody, nil
+}
+
+// markExpired 将接口声明的 csrf 过期状态码标记为 ErrTokenExpired
+func (r apiRequest) markExpired(err error) error {
+	var apiErr *APIError
+	if r.authed && r.expiredStatus != 0 && errors.As(err, &apiErr) && apiErr.Status == r.expiredStatus {
+		apiErr.Kind = ErrTokenExpired
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"hayfrp-cli/backoff"
+)
+
+// RetryPolicy 幂等请求的重试策略
+//
+// 只作用于声明为幂等的查询类请求（隧道列表、状态检查、用户信息、节点信息、版本等），
+// 添加、删除、签到等会修改数据的请求失败后不会重试。各请求是否幂等在对应的API方法中声明。
+type RetryPolicy struct {
+	MaxAttempts int           // 含首次请求在内的最大尝试次数，不大于 1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	MaxDelay    time.Duration // 等待时间上限
+	Jitter      float64       // 等待时间的随机抖动比例，0~1
+	RetryOn     []int         // 需要重试的 HTTP 状态码，网络错误与超时总是重试
+}
+
+// DefaultRetryPolicy 默认重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      0.2,
+	RetryOn:     []int{429, 502, 503, 504},
+}
+
+// NoRetry 不重试
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy 设置幂等请求的重试策略
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = &policy
+	}
+}
+
+// shouldRetry 判断错误是否值得重试
+func (p RetryPolicy) shouldRetry(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// 业务错误（登录过期、参数错误等）重试也不会成功
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.RetryOn, statusErr.StatusCode)
+	}
+
+	// 其余为网络错误、超时等
+	return true
+}
+
+// backoff 第 attempt 次失败后的等待时间
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return backoff.Exponential(p.BaseDelay, p.MaxDelay, p.Jitter, attempt)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w*time.Millisecond {
+			t.Errorf("backoff(%d) = %v，期望 %v", i+1, got, w*time.Millisecond)
+		}
+	}
+
+	// 位移溢出时使用上限
+	if got := p.backoff(80); got != time.Second {
+		t.Errorf("backoff(80) = %v，期望 %v", got, time.Second)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.2}
+	lo, hi := 800*time.Millisecond, 1200*time.Millisecond
+	seen := make(map[time.Duration]bool)
+	for range 200 {
+		d := p.backoff(1)
+		if d < lo || d > hi {
+			t.Fatalf("backoff(1) = %v，超出 [%v, %v]", d, lo, hi)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("抖动没有生效")
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection reset"), true},
+		{fmt.Errorf("所有API端点均不可用: %w", &HTTPStatusError{StatusCode: 503}), true},
+		{&HTTPStatusError{StatusCode: 500}, false},
+		{&HTTPStatusError{StatusCode: 429}, true},
+		{&APIError{Status: 500, Kind: ErrServer}, false},
+		{context.Canceled, false},
+		{fmt.Errorf("请求失败: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		if got := p.shouldRetry(tt.err); got != tt.want {
+			t.Errorf("shouldRetry(%v) = %v，期望 %v", tt.err, got, tt.want)
+		}
+	}
+}
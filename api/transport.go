@@ -0,0 +1,330 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeJSON = "application/json;charset=UTF-8"
+	contentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// Client 各API客户端共用的请求管道
+//
+// 负责设置请求头、故障转移、读取与解析响应，
+// UserAPIClient、ProxyAPIClient、NodeAPIClient 均通过它发起请求。
+type Client struct {
+	httpClient *http.Client
+	pool       *endpointPool
+	userAgent  string
+	retry      RetryPolicy
+	log        *slog.Logger // 为 nil 时使用包默认日志记录器
+	trace      bool
+	refresh    *tokenRefresh // 未设置 WithTokenRefresher 时为 nil
+}
+
+// newClient 根据配置项创建请求管道
+func newClient(opts ...Option) *Client {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	hc := o.httpClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	} else if o.transport != nil || o.timeout > 0 {
+		// 不修改调用方传入的 http.Client
+		copied := *hc
+		hc = &copied
+	}
+	if o.transport != nil {
+		hc.Transport = o.transport
+	}
+	if o.timeout > 0 {
+		hc.Timeout = o.timeout
+	}
+
+	pool := defaultPool
+	switch {
+	case o.pool != nil:
+		pool = o.pool
+	case len(o.endpoints) > 0:
+		pool = sharedPool(o.endpoints)
+	}
+
+	userAgent := o.userAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	retry := DefaultRetryPolicy
+	if o.retry != nil {
+		retry = *o.retry
+	}
+
+	var refresh *tokenRefresh
+	if o.refresher != nil {
+		refresh = &tokenRefresh{refresher: o.refresher}
+	}
+
+	return &Client{
+		httpClient: hc,
+		pool:       pool,
+		userAgent:  userAgent,
+		retry:      retry,
+		log:        o.logger,
+		trace:      o.trace,
+		refresh:    refresh,
+	}
+}
+
+// apiRequest 描述一次API调用
+type apiRequest struct {
+	method      string
+	path        string
+	body        []byte
+	contentType string
+	authed      bool // 是否携带 csrf，影响 403 的含义
+	idempotent  bool // 是否为可安全重试的查询请求
+
+	// expiredStatus 不为 0 时该状态码同样表示 csrf 过期
+	expiredStatus int
+
+	// Token 过期后刷新重放使用，rebuild 为 nil 表示不支持刷新
+	csrf    string
+	rebuild func(csrf string) ([]byte, error)
+}
+
+// do 发送请求并返回完整响应体，幂等请求按重试策略重试
+func (c *Client) do(ctx context.Context, r apiRequest) ([]byte, error) {
+	attempts := 1
+	if r.idempotent && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		respBody, err := c.doOnce(ctx, r)
+		if err == nil || attempt >= attempts || !c.retry.shouldRetry(err) {
+			return respBody, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		c.logger().Warn("请求失败，稍后重试",
+			"path", r.path, "delay", delay.Round(time.Millisecond),
+			"retry", attempt, "max_retries", attempts-1, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// doOnce 发送一次请求（含端点故障转移）并返回完整响应体
+func (c *Client) doOnce(ctx context.Context, r apiRequest) ([]byte, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, r.method, c.pool.currentURL()+r.path, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if r.contentType != "" {
+		httpReq.Header.Set("Content-Type", r.contentType)
+	}
+	httpReq.Header.Set("waf", "off")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+
+	log := c.logger()
+	if c.trace {
+		log.Debug("API请求", "method", r.method, "path", r.path, "body", redact(r.body))
+	}
+
+	start := time.Now()
+	resp, err := c.pool.do(c.httpClient, log, httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if c.trace {
+		log.Debug("API响应", "path", r.path, "status", resp.StatusCode,
+			"elapsed", time.Since(start).Round(time.Millisecond), "body", redact(respBody))
+	}
+
+	// API 的业务状态码放在JSON中，HTTP层面的错误只有在没有JSON可解析时才视为失败
+	if resp.StatusCode >= 400 && !isJSONObject(respBody) {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return respBody, nil
+}
+
+// callSpec 各API方法显式声明的调用方式
+type callSpec struct {
+	// idempotent 为 true 时请求失败后按 RetryPolicy 重试，
+	// 只有查询类请求（隧道列表、状态检查、用户信息等）可以这样声明
+	idempotent bool
+	// authed 请求携带 csrf，此时 403 表示 Token 无效
+	authed bool
+	// expiredStatus 接口以 403 以外的状态码表示 csrf 过期时声明该状态码，
+	// 如签到接口以 404 表示 csrf 无效或已过期
+	expiredStatus int
+
+	// csrf 为请求携带的 csrf，retoken 返回替换为新 csrf 后的请求，
+	// 用于 Token 过期后刷新重放；retoken 为 nil 表示不刷新
+	csrf    string
+	retoken func(csrf string) any
+}
+
+// call 以JSON提交请求并解析JSON响应
+func call[Req, Resp any](ctx context.Context, c *Client, path string, req Req, spec callSpec) (*Resp, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	return roundTrip[Resp](ctx, c, jsonRequest(path, body, spec))
+}
+
+// callForm 以表单提交请求并解析JSON响应
+func callForm[Resp any](ctx context.Context, c *Client, path string, form url.Values) (*Resp, error) {
+	r := apiRequest{
+		method:      http.MethodPost,
+		path:        path,
+		body:        []byte(form.Encode()),
+		contentType: contentTypeForm,
+		authed:      form.Get("csrf") != "",
+	}
+	if r.authed {
+		r.csrf = form.Get("csrf")
+		r.rebuild = func(csrf string) ([]byte, error) {
+			replaced := maps.Clone(form)
+			replaced.Set("csrf", csrf)
+			return []byte(replaced.Encode()), nil
+		}
+	}
+	return roundTrip[Resp](ctx, c, r)
+}
+
+// get 发送GET请求并解析JSON响应
+func get[Resp any](ctx context.Context, c *Client, path string) (*Resp, error) {
+	return roundTrip[Resp](ctx, c, apiRequest{
+		method:     http.MethodGet,
+		path:       path,
+		idempotent: true,
+	})
+}
+
+// roundTrip 发送请求并将响应解析为指定类型，业务状态码非成功时返回 *APIError
+func roundTrip[Resp any](ctx context.Context, c *Client, r apiRequest) (*Resp, error) {
+	respBody, err := c.exchange(ctx, r, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Resp
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// callText 以JSON提交请求并返回文本响应
+func callText[Req any](ctx context.Context, c *Client, path string, req Req, spec callSpec) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	return c.text(ctx, jsonRequest(path, body, spec))
+}
+
+// jsonRequest 描述以JSON提交的请求，声明了 retoken 的请求可在 Token 过期后刷新重放
+func jsonRequest(path string, body []byte, spec callSpec) apiRequest {
+	r := apiRequest{
+		method:      http.MethodPost,
+		path:        path,
+		body:        body,
+		contentType: contentTypeJSON,
+		authed:      spec.authed,
+		idempotent:  spec.idempotent,
+
+		expiredStatus: spec.expiredStatus,
+	}
+	if spec.retoken != nil {
+		r.csrf = spec.csrf
+		r.rebuild = func(csrf string) ([]byte, error) {
+			return json.Marshal(spec.retoken(csrf))
+		}
+	}
+	return r
+}
+
+// text 发送请求并返回文本响应，如果返回的是JSON错误则解析为error
+func (c *Client) text(ctx context.Context, r apiRequest) (string, error) {
+	// 返回JSON时按错误解析
+	respBody, err := c.exchange(ctx, r, false)
+	if err != nil {
+		return "", err
+	}
+
+	return string(respBody), nil
+}
+
+// envelope 所有JSON响应共有的状态字段
+type envelope struct {
+	Status  json.RawMessage `json:"status"`
+	Message string          `json:"message"`
+}
+
+// checkStatus 解析响应中的业务状态码并转换为错误
+//
+// 状态码通常是数字，用户信息接口成功时为 true、封禁时为 false，
+// 版本信息等接口没有状态字段，视为成功。
+func checkStatus(body []byte, authed bool) error {
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	raw := bytes.TrimSpace(env.Status)
+	switch string(raw) {
+	case "", "null", "true":
+		return nil
+	case "false":
+		return &APIError{Message: env.Message, Kind: ErrAccountDisabled}
+	}
+
+	status, err := strconv.Atoi(strings.Trim(string(raw), `"`))
+	if err != nil {
+		return fmt.Errorf("无法识别的状态码: %s", raw)
+	}
+	return classifyStatus(status, env.Message, authed)
+}
+
+// isJSONObject 判断响应体是否为JSON对象
+func isJSONObject(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return len(body) > 0 && body[0] == '{'
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		body   string
+		authed bool
+		want   error // nil 表示成功
+	}{
+		{`{"status":200,"message":"ok"}`, true, nil},
+		{`{"status":"200"}`, true, nil},
+		{`{"status":true}`, true, nil},
+		{`{"ver_hayfrps":"1.0"}`, false, nil},
+		{`{"status":null}`, false, nil},
+		{`{"status":false,"message":"封禁"}`, true, ErrAccountDisabled},
+		{`{"status":403,"message":"登录失效"}`, true, ErrTokenExpired},
+		{`{"status":"403","message":"密码错误"}`, false, ErrForbidden},
+		{`{"status":404}`, true, ErrMissingField},
+		{`{"status":500,"message":"节点离线"}`, true, ErrNodeOffline},
+	}
+	for _, tt := range tests {
+		err := checkStatus([]byte(tt.body), tt.authed)
+		if tt.want == nil && err != nil || !errors.Is(err, tt.want) {
+			t.Errorf("checkStatus(%s, %v) = %v，期望 %v", tt.body, tt.authed, err, tt.want)
+		}
+	}
+
+	for _, body := range []string{`{"status":"abc"}`, `not json`} {
+		if err := checkStatus([]byte(body), true); err == nil {
+			t.Errorf("checkStatus(%s) 应返回错误", body)
+		}
+	}
+}
+
+// testRetry 测试使用的重试策略，等待时间很短
+var testRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, RetryOn: []int{503}}
+
+// writeJSON 以JSON格式写入响应
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodeRequest 解析JSON请求体
+func decodeRequest(t *testing.T, r *http.Request) map[string]any {
+	t.Helper()
+	var req map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		t.Errorf("解析请求失败: %v", err)
+	}
+	return req
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, map[string]any{"status": 200, "proxies": []any{}})
+	}))
+	defer srv.Close()
+
+	client := NewProxyAPIClient(WithEndpoints(srv.URL), WithRetryPolicy(testRetry))
+	if _, err := client.ListTunnelContext(context.Background(), "csrf", ""); err != nil {
+		t.Fatalf("重试后应成功: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("请求次数 %d，期望 3", n)
+	}
+}
+
+func TestNoRetryForMutations(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewProxyAPIClient(WithEndpoints(srv.URL), WithRetryPolicy(testRetry))
+	_, err := client.DeleteTunnelContext(context.Background(), "csrf", "1")
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("期望 503 错误，实际 %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("删除隧道请求了 %d 次，不应重试", n)
+	}
+}
+
+func TestRetryStopsOnAPIError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, map[string]any{"status": 404, "message": "隧道不存在"})
+	}))
+	defer srv.Close()
+
+	client := NewProxyAPIClient(WithEndpoints(srv.URL), WithRetryPolicy(testRetry))
+	_, err := client.CheckTunnelContext(context.Background(), "csrf", "1")
+	if !errors.Is(err, ErrMissingField) {
+		t.Fatalf("期望 ErrMissingField，实际 %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("业务错误请求了 %d 次，不应重试", n)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	var primaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(t, r)
+		if req["type"] != "add" || req["proxy_name"] != "web" {
+			t.Errorf("备用端点收到的请求体不完整: %v", req)
+		}
+		writeJSON(w, map[string]any{"status": 200, "id": "9"})
+	}))
+	defer backup.Close()
+
+	client := NewProxyAPIClient(WithEndpoints(primary.URL, backup.URL), WithRetryPolicy(NoRetry))
+	resp, err := client.AddTunnelContext(context.Background(), &AddTunnelRequest{Type: "add", Csrf: "c", ProxyName: "web"})
+	if err != nil {
+		t.Fatalf("故障转移后应成功: %v", err)
+	}
+	if resp.ID != "9" {
+		t.Errorf("ID = %q", resp.ID)
+	}
+
+	// 切换后的请求直接发往备用端点
+	if _, err := client.AddTunnelContext(context.Background(), &AddTunnelRequest{Type: "add", Csrf: "c", ProxyName: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := primaryCalls.Load(); n != 1 {
+		t.Errorf("主端点请求了 %d 次，期望 1", n)
+	}
+	if got := client.client.pool.currentURL(); got != backup.URL {
+		t.Errorf("当前端点 %s，期望 %s", got, backup.URL)
+	}
+
+	// 让探测协程退出
+	client.client.pool.reset([]string{primary.URL, backup.URL})
+}
+
+func TestFailoverAllDown(t *testing.T) {
+	down := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }
+	a := httptest.NewServer(http.HandlerFunc(down))
+	defer a.Close()
+	b := httptest.NewServer(http.HandlerFunc(down))
+	defer b.Close()
+
+	client := NewNodeAPIClient(WithEndpoints(a.URL, b.URL), WithRetryPolicy(NoRetry))
+	_, err := client.GetVersionContext(context.Background())
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("期望 502 错误，实际 %v", err)
+	}
+}
+
+// tokenServer 模拟只接受 valid 作为 csrf 的服务器
+func tokenServer(t *testing.T, valid string, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		req := decodeRequest(t, r)
+		if req["csrf"] != valid {
+			writeJSON(w, map[string]any{"status": 403, "message": "登录失效"})
+			return
+		}
+		writeJSON(w, map[string]any{"status": 200, "message": "ok", "username": "alice"})
+	}))
+}
+
+func TestTokenRefreshReplay(t *testing.T) {
+	var calls atomic.Int32
+	srv := tokenServer(t, "fresh", &calls)
+	defer srv.Close()
+
+	var refreshed []string
+	refresher := func(ctx context.Context, expired string) (string, error) {
+		refreshed = append(refreshed, expired)
+		return "fresh", nil
+	}
+	client := NewUserAPIClient(WithEndpoints(srv.URL), WithTokenRefresher(refresher))
+
+	info, err := client.GetInfoContext(context.Background(), "stale")
+	if err != nil {
+		t.Fatalf("刷新后应成功: %v", err)
+	}
+	if info.Username != "alice" {
+		t.Errorf("Username = %q", info.Username)
+	}
+	if calls.Load() != 2 || len(refreshed) != 1 || refreshed[0] != "stale" {
+		t.Errorf("请求 %d 次，刷新记录 %v", calls.Load(), refreshed)
+	}
+
+	// 同一个过期 Token 不再重复刷新
+	if _, err := client.SignContext(context.Background(), "stale"); err != nil {
+		t.Fatal(err)
+	}
+	if len(refreshed) != 1 {
+		t.Errorf("同一个过期Token刷新了 %d 次", len(refreshed))
+	}
+}
+
+func TestSignRefreshOn404(t *testing.T) {
+	// 签到接口以 404 表示 csrf 无效或已过期
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if decodeRequest(t, r)["csrf"] != "fresh" {
+			writeJSON(w, map[string]any{"status": 404, "message": "CSRF临时授权无效或已过期."})
+			return
+		}
+		writeJSON(w, map[string]any{"status": 200, "message": "签到成功", "signflow": 1, "flow": 2})
+	}))
+	defer srv.Close()
+
+	var refreshed []string
+	client := NewUserAPIClient(WithEndpoints(srv.URL), WithTokenRefresher(func(ctx context.Context, expired string) (string, error) {
+		refreshed = append(refreshed, expired)
+		return "fresh", nil
+	}))
+
+	resp, err := client.SignContext(context.Background(), "stale")
+	if err != nil {
+		t.Fatalf("刷新后应成功: %v", err)
+	}
+	if resp.Signflow != 1 || calls.Load() != 2 || len(refreshed) != 1 {
+		t.Errorf("签到结果 %+v，请求 %d 次，刷新记录 %v", resp, calls.Load(), refreshed)
+	}
+
+	// 未设置刷新时返回 ErrTokenExpired 而不是 ErrMissingField
+	_, err = NewUserAPIClient(WithEndpoints(srv.URL)).SignContext(context.Background(), "stale")
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("错误 %v 应为 ErrTokenExpired", err)
+	}
+}
+
+func TestTokenRefreshFailed(t *testing.T) {
+	var calls atomic.Int32
+	srv := tokenServer(t, "fresh", &calls)
+	defer srv.Close()
+
+	offline := errors.New("网络不可用")
+	client := NewUserAPIClient(WithEndpoints(srv.URL), WithTokenRefresher(func(ctx context.Context, expired string) (string, error) {
+		return "", offline
+	}))
+
+	_, err := client.GetInfoContext(context.Background(), "stale")
+	for _, want := range []error{ErrTokenExpired, ErrRefreshFailed, offline} {
+		if !errors.Is(err, want) {
+			t.Errorf("错误 %v 应包含 %v", err, want)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("刷新失败后不应重放，实际请求 %d 次", calls.Load())
+	}
+}
+
+func TestVerifyCsrfDoesNotRefresh(t *testing.T) {
+	var calls atomic.Int32
+	srv := tokenServer(t, "fresh", &calls)
+	defer srv.Close()
+
+	client := NewUserAPIClient(WithEndpoints(srv.URL), WithTokenRefresher(func(ctx context.Context, expired string) (string, error) {
+		t.Error("验证Token时不应刷新")
+		return "fresh", nil
+	}))
+
+	_, err := client.VerifyCsrfContext(context.Background(), "stale")
+	if !errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrRefreshFailed) {
+		t.Errorf("期望仅为 ErrTokenExpired，实际 %v", err)
+	}
+}
+
+func TestForbiddenWithoutCsrf(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"status": 403, "message": "密码错误"})
+	}))
+	defer srv.Close()
+
+	client := NewUserAPIClient(WithEndpoints(srv.URL), WithTokenRefresher(func(ctx context.Context, expired string) (string, error) {
+		t.Error("登录请求不应刷新Token")
+		return "", nil
+	}))
+	_, err := client.LoginContext(context.Background(), "alice", "wrong")
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("期望 ErrForbidden，实际 %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bytes 流量大小，单位为字节
+//
+// API 返回的流量单位并不统一（剩余流量为 MB、签到总流量为 GB、今日流量为字节），
+// 解析时统一换算为字节，输出时按大小选择合适的单位。
+type Bytes int64
+
+// 流量单位
+const (
+	KB Bytes = 1024
+	MB       = 1024 * KB
+	GB       = 1024 * MB
+)
+
+// MB 换算为 MB
+func (b Bytes) MB() float64 {
+	return float64(b) / float64(MB)
+}
+
+// GB 换算为 GB
+func (b Bytes) GB() float64 {
+	return float64(b) / float64(GB)
+}
+
+// String 按大小格式化为 B/KB/MB/GB，如 "1.50 GB"
+func (b Bytes) String() string {
+	abs := b
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= GB:
+		return fmt.Sprintf("%.2f GB", b.GB())
+	case abs >= MB:
+		return fmt.Sprintf("%.2f MB", b.MB())
+	case abs >= KB:
+		return fmt.Sprintf("%.2f KB", float64(b)/float64(KB))
+	}
+	return fmt.Sprintf("%d B", int64(b))
+}
+
+// ParseBytes 解析带单位的流量大小，如 "1.5GB"、"500 MB"，单位不区分大小写，不带单位时为字节
+func ParseBytes(s string) (Bytes, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	unit := Bytes(1)
+	for _, u := range []struct {
+		suffix string
+		size   Bytes
+	}{{"GB", GB}, {"MB", MB}, {"KB", KB}, {"G", GB}, {"M", MB}, {"K", KB}, {"B", 1}} {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("无法解析流量大小 %q", s)
+	}
+	return Bytes(v * float64(unit)), nil
+}
+
+// 以下类型用于解析API中类型不固定的字段：同一字段可能是数字、数字字符串、
+// 布尔值、布尔字符串或 null，解析后转换为确定的 Go 类型。
+
+// flexString 字符串或数字
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	str, err := scalar(data)
+	*s = flexString(str)
+	return err
+}
+
+// flexFloat 数字或数字字符串，null 和空字符串为 0
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(data []byte) error {
+	str, err := scalar(data)
+	if err != nil || str == "" {
+		*f = 0
+		return err
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return fmt.Errorf("无法解析数字 %s", data)
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+// flexInt 整数或整数字符串，null 和空字符串为 0
+type flexInt int64
+
+func (i *flexInt) UnmarshalJSON(data []byte) error {
+	var f flexFloat
+	if err := f.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*i = flexInt(f)
+	return nil
+}
+
+// flexBool 布尔值、"true"/"false" 或数字（非零为真），null、空字符串及无法识别的值为 false
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	str, err := scalar(data)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(str) {
+	case "true", "1", "yes":
+		*b = true
+	case "false", "0", "no", "":
+		*b = false
+	default:
+		// 其他数字按非零为真处理，如状态码 200；无法识别的字符串视为 false
+		v, err := strconv.ParseFloat(str, 64)
+		*b = err == nil && v != 0
+	}
+	return nil
+}
+
+// flexTime Unix 时间戳（秒，数字或字符串）或日期字符串，null 和空字符串为零值
+type flexTime time.Time
+
+// dateLayouts 非时间戳形式的日期格式
+var dateLayouts = []string{time.DateTime, time.DateOnly, time.RFC3339}
+
+func (t *flexTime) UnmarshalJSON(data []byte) error {
+	str, err := scalar(data)
+	if err != nil || str == "" || str == "0" {
+		*t = flexTime{}
+		return err
+	}
+	if sec, err := strconv.ParseInt(str, 10, 64); err == nil {
+		*t = flexTime(time.Unix(sec, 0))
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if v, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			*t = flexTime(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析时间 %s", data)
+}
+
+// scalar 将JSON标量转换为字符串，null 与字符串 "null" 视为空
+func scalar(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		s = strings.TrimSpace(s)
+		if s == "null" {
+			return "", nil
+		}
+		return s, nil
+	}
+	if data[0] == '{' || data[0] == '[' {
+		return "", fmt.Errorf("期望标量，实际为 %s", data)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFlexString(t *testing.T) {
+	tests := map[string]string{
+		`"123"`:    "123",
+		`123`:      "123",
+		`" abc "`:  "abc",
+		`null`:     "",
+		`"null"`:   "",
+		`12345678`: "12345678",
+	}
+	for in, want := range tests {
+		var s flexString
+		if err := json.Unmarshal([]byte(in), &s); err != nil || string(s) != want {
+			t.Errorf("flexString(%s) = %q, %v，期望 %q", in, s, err, want)
+		}
+	}
+
+	var s flexString
+	if err := json.Unmarshal([]byte(`{"a":1}`), &s); err == nil {
+		t.Error("flexString 应拒绝对象")
+	}
+}
+
+func TestFlexInt(t *testing.T) {
+	tests := map[string]int64{
+		`12`:     12,
+		`"12"`:   12,
+		`"12.0"`: 12,
+		`null`:   0,
+		`""`:     0,
+		`-3`:     -3,
+	}
+	for in, want := range tests {
+		var i flexInt
+		if err := json.Unmarshal([]byte(in), &i); err != nil || int64(i) != want {
+			t.Errorf("flexInt(%s) = %d, %v，期望 %d", in, i, err, want)
+		}
+	}
+
+	var i flexInt
+	if err := json.Unmarshal([]byte(`"abc"`), &i); err == nil {
+		t.Error("flexInt 应拒绝非数字字符串")
+	}
+}
+
+func TestFlexTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`1700000000`, time.Unix(1700000000, 0)},
+		{`"1700000000"`, time.Unix(1700000000, 0)},
+		{`"2024-05-01 08:30:00"`, time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local)},
+		{`"2024-05-01"`, time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{`"2024-05-01T08:30:00Z"`, time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+		{`0`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var ft flexTime
+		if err := json.Unmarshal([]byte(tt.in), &ft); err != nil || !time.Time(ft).Equal(tt.want) {
+			t.Errorf("flexTime(%s) = %v, %v，期望 %v", tt.in, time.Time(ft), err, tt.want)
+		}
+	}
+
+	var ft flexTime
+	if err := json.Unmarshal([]byte(`"昨天"`), &ft); err == nil {
+		t.Error("flexTime 应拒绝无法识别的日期")
+	}
+}
+
+func TestUserInfoUnmarshal(t *testing.T) {
+	data := `{
+		"status": "true",
+		"message": "获取成功",
+		"id": "42",
+		"username": "alice",
+		"traffic": "1536",
+		"realname": 1,
+		"proxies": "10",
+		"useproxies": 3,
+		"regtime": "1700000000",
+		"signdate": null,
+		"totalsign": "7",
+		"totaltraffic": 2.5,
+		"todaytraffic": "2048",
+		"qid": 10001,
+		"sprovider": "false"
+	}`
+
+	var u UserInfo
+	if err := json.Unmarshal([]byte(data), &u); err != nil {
+		t.Fatal(err)
+	}
+
+	if !u.Status || !u.Realname || u.Sprovider {
+		t.Errorf("布尔字段解析错误: status=%v realname=%v sprovider=%v", u.Status, u.Realname, u.Sprovider)
+	}
+	if u.ID != 42 || u.Proxies != 10 || u.Useproxies != 3 || u.Totalsign != 7 {
+		t.Errorf("整数字段解析错误: %+v", u)
+	}
+	if u.Qid != "10001" {
+		t.Errorf("Qid = %q", u.Qid)
+	}
+	// 剩余流量单位为 MB，签到总流量为 GB，今日流量为字节
+	if u.Traffic != 1536*MB {
+		t.Errorf("Traffic = %v，期望 1.50 GB", u.Traffic)
+	}
+	if u.Totaltraffic != Bytes(2.5*float64(GB)) {
+		t.Errorf("Totaltraffic = %v，期望 2.50 GB", u.Totaltraffic)
+	}
+	if u.Todaytraffic != 2*KB {
+		t.Errorf("Todaytraffic = %v，期望 2.00 KB", u.Todaytraffic)
+	}
+	if !u.Regtime.Equal(time.Unix(1700000000, 0)) || !u.Signdate.IsZero() {
+		t.Errorf("时间字段解析错误: regtime=%v signdate=%v", u.Regtime, u.Signdate)
+	}
+}
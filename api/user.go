@@ -1,25 +1,20 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"time"
 )
 
 // UserAPIClient 用户相关API客户端
 type UserAPIClient struct {
-	client *http.Client
+	client *Client
 }
 
 // NewUserAPIClient 创建用户API客户端
-func NewUserAPIClient() *UserAPIClient {
+func NewUserAPIClient(opts ...Option) *UserAPIClient {
 	return &UserAPIClient{
-		client: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		client: newClient(opts...),
 	}
 }
 
@@ -39,40 +34,17 @@ type LoginResponse struct {
 
 // Login 登录获取Token
 func (c *UserAPIClient) Login(user, passwd string) (*LoginResponse, error) {
+	return c.LoginContext(context.Background(), user, passwd)
+}
+
+// LoginContext 登录获取Token，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) LoginContext(ctx context.Context, user, passwd string) (*LoginResponse, error) {
 	req := LoginRequest{
 		Type:   "login",
 		User:   user,
 		Passwd: passwd,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result LoginResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[LoginRequest, LoginResponse](ctx, c.client, "/user", req, callSpec{})
 }
 
 // CsrfRequest 验证Token请求
@@ -90,39 +62,20 @@ type CsrfResponse struct {
 
 // VerifyCsrf 验证Token是否有效
 func (c *UserAPIClient) VerifyCsrf(csrf string) (*CsrfResponse, error) {
+	return c.VerifyCsrfContext(context.Background(), csrf)
+}
+
+// VerifyCsrfContext 验证Token是否有效，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) VerifyCsrfContext(ctx context.Context, csrf string) (*CsrfResponse, error) {
 	req := CsrfRequest{
 		Type: "csrf",
 		Csrf: csrf,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result CsrfResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[CsrfRequest, CsrfResponse](ctx, c.client, "/user", req, callSpec{
+		idempotent: true,
+		// 验证 Token 的请求需要如实返回 Token 状态，不刷新
+		authed: true,
+	})
 }
 
 // SendRegCodeRequest 发送注册验证码请求
@@ -141,41 +94,18 @@ type SendRegCodeResponse struct {
 
 // SendRegCode 发送注册验证码
 func (c *UserAPIClient) SendRegCode(user, device, email string) (*SendRegCodeResponse, error) {
+	return c.SendRegCodeContext(context.Background(), user, device, email)
+}
+
+// SendRegCodeContext 发送注册验证码，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) SendRegCodeContext(ctx context.Context, user, device, email string) (*SendRegCodeResponse, error) {
 	req := SendRegCodeRequest{
 		Type:   "sendregcode",
 		User:   user,
 		Device: device,
 		Email:  email,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result SendRegCodeResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[SendRegCodeRequest, SendRegCodeResponse](ctx, c.client, "/user", req, callSpec{})
 }
 
 // RegisterRequest 注册请求
@@ -196,6 +126,11 @@ type RegisterResponse struct {
 
 // Register 注册新用户
 func (c *UserAPIClient) Register(user, device, email, passwd, regcode string) (*RegisterResponse, error) {
+	return c.RegisterContext(context.Background(), user, device, email, passwd, regcode)
+}
+
+// RegisterContext 注册新用户，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) RegisterContext(ctx context.Context, user, device, email, passwd, regcode string) (*RegisterResponse, error) {
 	req := RegisterRequest{
 		Type:    "register",
 		User:    user,
@@ -204,35 +139,7 @@ func (c *UserAPIClient) Register(user, device, email, passwd, regcode string) (*
 		Passwd:  passwd,
 		Regcode: regcode,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result RegisterResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[RegisterRequest, RegisterResponse](ctx, c.client, "/user", req, callSpec{})
 }
 
 // GetInfoRequest 获取用户信息请求
@@ -242,62 +149,102 @@ type GetInfoRequest struct {
 }
 
 // UserInfo 用户信息
+//
+// API 中多数字段的类型并不固定（数字或数字字符串、布尔值或 "true"/"false"、
+// null），解析时统一转换，未返回的字段为零值。
 type UserInfo struct {
-	Status       bool        `json:"status"`
-	Message      string      `json:"message"`
-	ID           interface{} `json:"id"`
-	Username     string      `json:"username"`
-	Token        string      `json:"token"`
-	Email        string      `json:"email"`
-	Traffic      interface{} `json:"traffic"`
-	Realname     interface{} `json:"realname"`     // 可能是 string 或 bool
-	Proxies      interface{} `json:"proxies"`
-	Useproxies   interface{} `json:"useproxies"`
-	Regtime      interface{} `json:"regtime"`
-	Signdate     string      `json:"signdate"`
-	Totalsign    interface{} `json:"totalsign"`
-	Totaltraffic interface{} `json:"totaltraffic"`
-	Todaytraffic interface{} `json:"todaytraffic"`
-	Qid          interface{} `json:"qid"`
-	Sprovider    interface{} `json:"sprovider"`    // 可能是 string 或 bool
-	UUID         string      `json:"uuid"`
+	Status       bool      // 账号状态，false 表示已封禁
+	Message      string    // 提示信息
+	ID           int64     // 账号ID
+	Username     string    // 用户名
+	Token        string    // Frp 链接 Token
+	Email        string    // 邮箱
+	Traffic      Bytes     // 剩余流量
+	Realname     bool      // 是否已实名认证
+	Proxies      int64     // 拥有隧道数
+	Useproxies   int64     // 已使用隧道数
+	Regtime      time.Time // 注册时间
+	Signdate     time.Time // 上次签到时间，从未签到时为零值
+	Totalsign    int64     // 总签到天数
+	Totaltraffic Bytes     // 总签到流量
+	Todaytraffic Bytes     // 今日使用流量
+	Qid          string    // 头像使用的QQ号
+	Sprovider    bool      // 是否为服务商账户
+	UUID         string    // 唯一用户标识符
+}
+
+// userInfoJSON GetInfo 接口返回的原始字段
+type userInfoJSON struct {
+	Status       flexBool   `json:"status"`
+	Message      string     `json:"message"`
+	ID           flexInt    `json:"id"`
+	Username     string     `json:"username"`
+	Token        string     `json:"token"`
+	Email        string     `json:"email"`
+	Traffic      flexFloat  `json:"traffic"` // MB
+	Realname     flexBool   `json:"realname"`
+	Proxies      flexInt    `json:"proxies"`
+	Useproxies   flexInt    `json:"useproxies"`
+	Regtime      flexTime   `json:"regtime"`
+	Signdate     flexTime   `json:"signdate"`
+	Totalsign    flexInt    `json:"totalsign"`
+	Totaltraffic flexFloat  `json:"totaltraffic"` // GB
+	Todaytraffic flexFloat  `json:"todaytraffic"` // Bytes
+	Qid          flexString `json:"qid"`
+	Sprovider    flexBool   `json:"sprovider"`
+	UUID         string     `json:"uuid"`
+}
+
+// UnmarshalJSON 解析用户信息并换算流量单位
+func (u *UserInfo) UnmarshalJSON(data []byte) error {
+	var raw userInfoJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*u = UserInfo{
+		Status:       bool(raw.Status),
+		Message:      raw.Message,
+		ID:           int64(raw.ID),
+		Username:     raw.Username,
+		Token:        raw.Token,
+		Email:        raw.Email,
+		Traffic:      Bytes(float64(raw.Traffic) * float64(MB)),
+		Realname:     bool(raw.Realname),
+		Proxies:      int64(raw.Proxies),
+		Useproxies:   int64(raw.Useproxies),
+		Regtime:      time.Time(raw.Regtime),
+		Signdate:     time.Time(raw.Signdate),
+		Totalsign:    int64(raw.Totalsign),
+		Totaltraffic: Bytes(float64(raw.Totaltraffic) * float64(GB)),
+		Todaytraffic: Bytes(raw.Todaytraffic),
+		Qid:          string(raw.Qid),
+		Sprovider:    bool(raw.Sprovider),
+		UUID:         raw.UUID,
+	}
+	return nil
 }
 
 // GetInfo 获取用户信息
 func (c *UserAPIClient) GetInfo(csrf string) (*UserInfo, error) {
+	return c.GetInfoContext(context.Background(), csrf)
+}
+
+// GetInfoContext 获取用户信息，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) GetInfoContext(ctx context.Context, csrf string) (*UserInfo, error) {
 	req := GetInfoRequest{
 		Type: "info",
 		Csrf: csrf,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result UserInfo
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[GetInfoRequest, UserInfo](ctx, c.client, "/user", req, callSpec{
+		idempotent: true,
+		authed:     true,
+		csrf:       req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // SignRequest 签到请求
@@ -316,39 +263,24 @@ type SignResponse struct {
 
 // Sign 签到
 func (c *UserAPIClient) Sign(csrf string) (*SignResponse, error) {
+	return c.SignContext(context.Background(), csrf)
+}
+
+// SignContext 签到，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) SignContext(ctx context.Context, csrf string) (*SignResponse, error) {
 	req := SignRequest{
 		Type: "sign",
 		Csrf: csrf,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result SignResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[SignRequest, SignResponse](ctx, c.client, "/user", req, callSpec{
+		authed:        true,
+		expiredStatus: 404,
+		csrf:          req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // ReTokenRequest 更新Token请求
@@ -366,39 +298,23 @@ type ReTokenResponse struct {
 
 // ReToken 更新用户Token
 func (c *UserAPIClient) ReToken(csrf string) (*ReTokenResponse, error) {
+	return c.ReTokenContext(context.Background(), csrf)
+}
+
+// ReTokenContext 更新用户Token，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) ReTokenContext(ctx context.Context, csrf string) (*ReTokenResponse, error) {
 	req := ReTokenRequest{
 		Type: "retoken",
 		Csrf: csrf,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result ReTokenResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[ReTokenRequest, ReTokenResponse](ctx, c.client, "/user", req, callSpec{
+		authed: true,
+		csrf:   req.Csrf,
+		retoken: func(csrf string) any {
+			req.Csrf = csrf
+			return req
+		},
+	})
 }
 
 // FindPassEmRequest 重置密码发送验证码请求
@@ -415,39 +331,16 @@ type FindPassEmResponse struct {
 
 // SendFindPassCode 发送重置密码验证码
 func (c *UserAPIClient) SendFindPassCode(user string) (*FindPassEmResponse, error) {
+	return c.SendFindPassCodeContext(context.Background(), user)
+}
+
+// SendFindPassCodeContext 发送重置密码验证码，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) SendFindPassCodeContext(ctx context.Context, user string) (*FindPassEmResponse, error) {
 	req := FindPassEmRequest{
 		Type: "findpassem",
 		User: user,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result FindPassEmResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[FindPassEmRequest, FindPassEmResponse](ctx, c.client, "/user", req, callSpec{})
 }
 
 // FindPassCtRequest 重置密码请求
@@ -465,38 +358,15 @@ type FindPassCtResponse struct {
 
 // ResetPass 重置密码
 func (c *UserAPIClient) ResetPass(token, newpass string) (*FindPassCtResponse, error) {
+	return c.ResetPassContext(context.Background(), token, newpass)
+}
+
+// ResetPassContext 重置密码，可通过 ctx 取消请求或设置超时
+func (c *UserAPIClient) ResetPassContext(ctx context.Context, token, newpass string) (*FindPassCtResponse, error) {
 	req := FindPassCtRequest{
 		Type:    "findpassct",
 		Token:   token,
 		Newpass: newpass,
 	}
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	httpReq, err := http.NewRequest("POST", BaseURL+"/user", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	httpReq.Header.Set("waf", "off")
-
-	resp, err := DoRequestWithFallback(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var result FindPassCtResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	return &result, nil
+	return call[FindPassCtRequest, FindPassCtResponse](ctx, c.client, "/user", req, callSpec{})
 }
@@ -0,0 +1,24 @@
+// Package backoff 计算带上限与随机抖动的指数退避等待时间
+//
+// API 请求重试（api.RetryPolicy）与 frpc 崩溃重启（supervisor.Policy）共用该实现。
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Exponential 连续第 attempt 次失败（从 1 开始）后的等待时间
+//
+// 等待时间从 base 开始每次翻倍，max 大于 0 时不超过 max；jitter 大于 0 时
+// 在结果上随机增减最多 jitter 比例，如 0.2 表示 ±20%。
+func Exponential(base, max time.Duration, jitter float64, attempt int) time.Duration {
+	delay := base << (attempt - 1)
+	if max > 0 && (delay > max || delay <= 0) {
+		delay = max
+	}
+	if jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + jitter*(rand.Float64()*2-1)))
+	}
+	return delay
+}
@@ -0,0 +1,28 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := Exponential(100*time.Millisecond, time.Second, 0, i+1); got != w*time.Millisecond {
+			t.Errorf("Exponential(%d) = %v，期望 %v", i+1, got, w*time.Millisecond)
+		}
+	}
+	// 移位溢出时使用上限
+	if got := Exponential(time.Second, time.Minute, 0, 100); got != time.Minute {
+		t.Errorf("Exponential(100) = %v，期望 %v", got, time.Minute)
+	}
+}
+
+func TestExponentialJitter(t *testing.T) {
+	lo, hi := 80*time.Millisecond, 120*time.Millisecond
+	for range 100 {
+		if d := Exponential(100*time.Millisecond, time.Second, 0.2, 1); d < lo || d > hi {
+			t.Fatalf("Exponential(1) = %v，超出 [%v, %v]", d, lo, hi)
+		}
+	}
+}
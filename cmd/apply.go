@@ -0,0 +1,596 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hayfrp-cli/api"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// tunnelTypes 支持的隧道类型
+var tunnelTypes = []string{"tcp", "udp", "http", "https", "xtcp", "stcp"}
+
+// tunnelManifest 声明式隧道清单，描述账户应有的全部隧道
+type tunnelManifest struct {
+	Tunnels []tunnelSpec `yaml:"tunnels" toml:"tunnels" json:"tunnels"`
+}
+
+// tunnelSpec 清单中的一条隧道，按名称与已有隧道对应
+type tunnelSpec struct {
+	Name        string `yaml:"name" toml:"name" json:"name"`
+	Type        string `yaml:"type" toml:"type" json:"type"`
+	LocalIP     string `yaml:"local_ip" toml:"local_ip" json:"local_ip"`
+	LocalPort   int    `yaml:"local_port" toml:"local_port" json:"local_port"`
+	RemotePort  int    `yaml:"remote_port,omitempty" toml:"remote_port,omitempty" json:"remote_port,omitempty"`
+	Node        string `yaml:"node" toml:"node" json:"node"`
+	Domain      string `yaml:"domain,omitempty" toml:"domain,omitempty" json:"domain,omitempty"`
+	Encryption  bool   `yaml:"encryption" toml:"encryption" json:"encryption"`
+	Compression bool   `yaml:"compression" toml:"compression" json:"compression"`
+	SK          string `yaml:"sk,omitempty" toml:"sk,omitempty" json:"sk,omitempty"`
+	// 以下字段为空时沿用隧道现有的值
+	Locations         string `yaml:"locations,omitempty" toml:"locations,omitempty" json:"locations,omitempty"`
+	HostHeaderRewrite string `yaml:"host_header_rewrite,omitempty" toml:"host_header_rewrite,omitempty" json:"host_header_rewrite,omitempty"`
+	HeaderXFromWhere  string `yaml:"header_x_from_where,omitempty" toml:"header_x_from_where,omitempty" json:"header_x_from_where,omitempty"`
+	// Enabled 为空时不改变隧道的启用状态
+	Enabled *bool `yaml:"enabled,omitempty" toml:"enabled,omitempty" json:"enabled,omitempty"`
+}
+
+// tunnelSpecFromInfo 将已有隧道转换为清单条目，去掉ID、更新时间等由服务端维护的字段
+func tunnelSpecFromInfo(t api.TunnelInfo) (tunnelSpec, error) {
+	localPort, err := strconv.Atoi(t.LocalPort)
+	if err != nil {
+		return tunnelSpec{}, fmt.Errorf("隧道 %s 的本地端口 %q 无效", t.ProxyName, t.LocalPort)
+	}
+	remotePort, err := strconv.Atoi(normalizePort(t.RemotePort))
+	if err != nil {
+		return tunnelSpec{}, fmt.Errorf("隧道 %s 的远程端口 %q 无效", t.ProxyName, t.RemotePort)
+	}
+	enabled := t.Status == "true"
+	return tunnelSpec{
+		Name:              t.ProxyName,
+		Type:              t.ProxyType,
+		LocalIP:           t.LocalIP,
+		LocalPort:         localPort,
+		RemotePort:        remotePort,
+		Node:              t.Node,
+		Domain:            t.Domain,
+		Encryption:        normalizeBool(t.UseEncryption) == "true",
+		Compression:       normalizeBool(t.UseCompression) == "true",
+		SK:                t.SK,
+		Locations:         t.Locations,
+		HostHeaderRewrite: t.HostHeaderRewrite,
+		HeaderXFromWhere:  t.HeaderXFromWhere,
+		Enabled:           &enabled,
+	}, nil
+}
+
+// encodeTunnelManifest 将清单编码为 yaml、json 或 toml
+func encodeTunnelManifest(m *tunnelManifest, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "yaml", "yml":
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+		enc.Close()
+	case "json":
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	case "toml":
+		if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的格式 %s (yaml/json/toml)", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// manifestFormat 按扩展名返回清单格式，无法识别时返回空字符串
+func manifestFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// readTunnelManifest 读取隧道清单，按扩展名解析 YAML、JSON 或 TOML，path 为 - 时从标准输入读取 YAML
+func readTunnelManifest(path string, stdin io.Reader) (*tunnelManifest, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	format := manifestFormat(path)
+	if filepath.Ext(path) == "" {
+		format = "yaml"
+	}
+
+	var manifest tunnelManifest
+	switch format {
+	case "toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&manifest)
+		var strictErr *toml.StrictMissingError
+		if errors.As(err, &strictErr) {
+			err = fmt.Errorf("未知的字段\n%s", strictErr.String())
+		}
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&manifest)
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(&manifest); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	default:
+		return nil, fmt.Errorf("不支持的清单格式 %s，请使用 .yaml、.json 或 .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// validate 检查清单并补全默认值
+func (m *tunnelManifest) validate() error {
+	seen := make(map[string]bool)
+	for i := range m.Tunnels {
+		s := &m.Tunnels[i]
+		if s.Name == "" {
+			return fmt.Errorf("第 %d 条隧道缺少 name", i+1)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("隧道 %s 重复定义", s.Name)
+		}
+		seen[s.Name] = true
+
+		s.Type = strings.ToLower(s.Type)
+		if !slices.Contains(tunnelTypes, s.Type) {
+			return fmt.Errorf("隧道 %s 的类型 %q 无效 (%s)", s.Name, s.Type, strings.Join(tunnelTypes, "/"))
+		}
+		if s.LocalIP == "" {
+			s.LocalIP = "127.0.0.1"
+		}
+		if s.LocalPort <= 0 || s.LocalPort > 65535 {
+			return fmt.Errorf("隧道 %s 的 local_port 无效", s.Name)
+		}
+		if s.RemotePort < 0 || s.RemotePort > 65535 {
+			return fmt.Errorf("隧道 %s 的 remote_port 无效", s.Name)
+		}
+		if s.Node == "" {
+			return fmt.Errorf("隧道 %s 缺少 node", s.Name)
+		}
+		if (s.Type == "http" || s.Type == "https") && s.Domain == "" {
+			return fmt.Errorf("%s 隧道 %s 缺少 domain", s.Type, s.Name)
+		}
+	}
+	return nil
+}
+
+// tunnelChange 隧道的一项字段变更
+type tunnelChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// diffTunnel 比较已有隧道与清单，返回需要修改的字段，不包含启用状态
+func diffTunnel(t api.TunnelInfo, s tunnelSpec) []tunnelChange {
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"type", t.ProxyType, s.Type},
+		{"local_ip", t.LocalIP, s.LocalIP},
+		{"local_port", t.LocalPort, strconv.Itoa(s.LocalPort)},
+		{"remote_port", normalizePort(t.RemotePort), strconv.Itoa(s.RemotePort)},
+		{"node", t.Node, s.Node},
+		{"domain", t.Domain, s.Domain},
+		{"encryption", normalizeBool(t.UseEncryption), strconv.FormatBool(s.Encryption)},
+		{"compression", normalizeBool(t.UseCompression), strconv.FormatBool(s.Compression)},
+		{"sk", t.SK, s.SK},
+	}
+	// 清单中为空的高级选项沿用现有的值，不比较
+	for _, f := range []struct {
+		name     string
+		from, to string
+	}{
+		{"locations", t.Locations, s.Locations},
+		{"host_header_rewrite", t.HostHeaderRewrite, s.HostHeaderRewrite},
+		{"header_x_from_where", t.HeaderXFromWhere, s.HeaderXFromWhere},
+	} {
+		if f.to != "" {
+			fields = append(fields, f)
+		}
+	}
+	var changes []tunnelChange
+	for _, f := range fields {
+		if f.from != f.to {
+			changes = append(changes, tunnelChange{Field: f.name, From: f.from, To: f.to})
+		}
+	}
+	return changes
+}
+
+// normalizePort 接口中未设置的端口可能为空
+func normalizePort(port string) string {
+	if port == "" {
+		return "0"
+	}
+	return port
+}
+
+// normalizeBool 接口中的布尔值可能为 true/false 或 1/0
+func normalizeBool(s string) string {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return "false"
+	}
+	return strconv.FormatBool(b)
+}
+
+// planAction 计划中的操作类型
+type planAction int
+
+const (
+	actionCreate planAction = iota
+	actionUpdate
+	actionToggle
+	actionDelete
+)
+
+// planStep 使账户隧道与清单一致的一步操作
+type planStep struct {
+	Action  planAction
+	Spec    tunnelSpec     // 创建、修改、切换状态时的目标
+	Current api.TunnelInfo // 修改、切换状态、删除时的已有隧道
+	Changes []tunnelChange // 修改的字段
+	Enable  bool           // 切换状态的目标状态
+}
+
+// String 以一行文字描述该操作
+func (s planStep) String() string {
+	switch s.Action {
+	case actionCreate:
+		line := fmt.Sprintf("+ 创建 %s (%s %s:%d → 节点 %s", s.Spec.Name, s.Spec.Type, s.Spec.LocalIP, s.Spec.LocalPort, s.Spec.Node)
+		if s.Spec.Domain != "" {
+			line += " " + s.Spec.Domain
+		} else if s.Spec.RemotePort > 0 {
+			line += fmt.Sprintf(":%d", s.Spec.RemotePort)
+		}
+		return line + ")"
+	case actionUpdate:
+		parts := make([]string, len(s.Changes))
+		for i, c := range s.Changes {
+			from, to := c.From, c.To
+			if c.Field == "sk" {
+				from, to = maskSecret(from), maskSecret(to)
+			}
+			parts[i] = fmt.Sprintf("%s: %q → %q", c.Field, from, to)
+		}
+		return fmt.Sprintf("~ 修改 %s (ID %s)\n    %s", s.Current.ProxyName, s.Current.ID, strings.Join(parts, "\n    "))
+	case actionToggle:
+		ref := fmt.Sprintf("%s (ID %s)", s.Current.ProxyName, s.Current.ID)
+		if s.Current.ID == "" {
+			ref = s.Spec.Name + " (新建)"
+		}
+		if s.Enable {
+			return "± 启用 " + ref
+		}
+		return "± 禁用 " + ref
+	case actionDelete:
+		return fmt.Sprintf("- 删除 %s (ID %s)", s.Current.ProxyName, s.Current.ID)
+	}
+	return ""
+}
+
+// summary 简述该操作，如“创建 web”
+func (s planStep) summary() string {
+	switch s.Action {
+	case actionCreate:
+		return "创建 " + s.Spec.Name
+	case actionUpdate:
+		return "修改 " + s.Spec.Name
+	case actionToggle:
+		if s.Enable {
+			return "启用 " + s.Spec.Name
+		}
+		return "禁用 " + s.Spec.Name
+	case actionDelete:
+		return "删除 " + s.Current.ProxyName
+	}
+	return ""
+}
+
+// maskSecret 隐藏 SK 密钥，只显示是否设置
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "******"
+}
+
+// planTunnels 比较账户已有隧道与清单，生成操作计划
+//
+// 已有隧道按名称与清单对应；清单中没有的隧道在 prune 时删除，否则列入 unmanaged。
+// 删除排在最前，以便释放被占用的名称与端口。
+func planTunnels(current []api.TunnelInfo, manifest *tunnelManifest, prune bool) (steps []planStep, unmanaged []api.TunnelInfo) {
+	byName := make(map[string]api.TunnelInfo, len(current))
+	for _, t := range current {
+		byName[t.ProxyName] = t
+	}
+	wanted := make(map[string]bool, len(manifest.Tunnels))
+	for _, s := range manifest.Tunnels {
+		wanted[s.Name] = true
+	}
+
+	for _, t := range current {
+		if wanted[t.ProxyName] {
+			continue
+		}
+		if prune {
+			steps = append(steps, planStep{Action: actionDelete, Current: t})
+		} else {
+			unmanaged = append(unmanaged, t)
+		}
+	}
+
+	for _, s := range manifest.Tunnels {
+		t, ok := byName[s.Name]
+		if !ok {
+			steps = append(steps, planStep{Action: actionCreate, Spec: s})
+			if s.Enabled != nil && !*s.Enabled {
+				steps = append(steps, planStep{Action: actionToggle, Spec: s, Current: api.TunnelInfo{ProxyName: s.Name}})
+			}
+			continue
+		}
+		if changes := diffTunnel(t, s); len(changes) > 0 {
+			steps = append(steps, planStep{Action: actionUpdate, Spec: s, Current: t, Changes: changes})
+		}
+		if s.Enabled != nil && *s.Enabled != (t.Status == "true") {
+			steps = append(steps, planStep{Action: actionToggle, Spec: s, Current: t, Enable: *s.Enabled})
+		}
+	}
+	return steps, unmanaged
+}
+
+// applyStep 执行一步操作，created 记录新建隧道的名称与ID，供随后切换状态使用
+func applyStep(ctx context.Context, client *api.ProxyAPIClient, csrf string, step planStep, created map[string]string) error {
+	s := step.Spec
+	switch step.Action {
+	case actionCreate:
+		resp, err := client.AddTunnelContext(ctx, &api.AddTunnelRequest{
+			Type:              "add",
+			Csrf:              csrf,
+			ProxyName:         s.Name,
+			ProxyType:         s.Type,
+			LocalIP:           s.LocalIP,
+			LocalPort:         s.LocalPort,
+			RemotePort:        s.RemotePort,
+			UseEncryption:     strconv.FormatBool(s.Encryption),
+			UseCompression:    strconv.FormatBool(s.Compression),
+			SK:                s.SK,
+			Node:              s.Node,
+			Domain:            s.Domain,
+			Locations:         s.Locations,
+			HeaderXFromWhere:  s.HeaderXFromWhere,
+			HostHeaderRewrite: s.HostHeaderRewrite,
+		})
+		if err != nil {
+			return err
+		}
+		created[s.Name] = resp.ID
+		return nil
+	case actionUpdate:
+		// 清单未描述的字段沿用隧道现有的值
+		t := step.Current
+		_, err := client.EditTunnelContext(ctx, &api.EditTunnelRequest{
+			Type:              "edit",
+			Csrf:              csrf,
+			ID:                t.ID,
+			ProxyName:         s.Name,
+			ProxyType:         s.Type,
+			LocalIP:           s.LocalIP,
+			LocalPort:         s.LocalPort,
+			RemotePort:        s.RemotePort,
+			UseEncryption:     strconv.FormatBool(s.Encryption),
+			UseCompression:    strconv.FormatBool(s.Compression),
+			SK:                s.SK,
+			Node:              s.Node,
+			Domain:            s.Domain,
+			Locations:         cmp.Or(s.Locations, t.Locations),
+			HeaderXFromWhere:  cmp.Or(s.HeaderXFromWhere, t.HeaderXFromWhere),
+			HostHeaderRewrite: cmp.Or(s.HostHeaderRewrite, t.HostHeaderRewrite),
+		})
+		return err
+	case actionToggle:
+		id := step.Current.ID
+		if id == "" {
+			id = created[s.Name]
+		}
+		if id == "" {
+			// 创建隧道的响应中没有ID时按名称查找
+			listResp, err := client.ListTunnelContext(ctx, csrf, "")
+			if err != nil {
+				return err
+			}
+			for _, t := range listResp.Proxies {
+				if t.ProxyName == s.Name {
+					id = t.ID
+				}
+			}
+			if id == "" {
+				return fmt.Errorf("未找到新建的隧道 %s", s.Name)
+			}
+		}
+		_, err := client.ToggleTunnelContext(ctx, csrf, id, strconv.FormatBool(step.Enable))
+		return err
+	case actionDelete:
+		_, err := client.DeleteTunnelContext(ctx, csrf, step.Current.ID)
+		return err
+	}
+	return nil
+}
+
+// applyPlan 依次执行计划中的操作并输出结果，返回失败与跳过的操作数
+//
+// 某条隧道创建失败时，随后对它的启用/禁用操作不再执行；其余操作照常进行。
+func applyPlan(ctx context.Context, client *api.ProxyAPIClient, csrf string, steps []planStep, w io.Writer) (failed, skipped int) {
+	created := make(map[string]string)
+	notCreated := make(map[string]bool)
+	for _, step := range steps {
+		if step.Action == actionToggle && step.Current.ID == "" && notCreated[step.Spec.Name] {
+			fmt.Fprintf(w, "- 跳过%s: 隧道未能创建\n", step.summary())
+			skipped++
+			continue
+		}
+		if err := applyStep(ctx, client, csrf, step, created); err != nil {
+			fmt.Fprintf(w, "✗ %s 失败: %v\n", step.summary(), err)
+			failed++
+			if step.Action == actionCreate {
+				notCreated[step.Spec.Name] = true
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		fmt.Fprintf(w, "✓ 已%s\n", step.summary())
+	}
+	return failed, skipped
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "按清单文件同步隧道",
+	Long: `按 YAML、JSON 或 TOML 清单文件创建、修改、启用/禁用隧道，使账户的隧道与清单一致。
+
+清单中的隧道按名称与已有隧道对应：不存在的创建，字段不同的修改，
+设置了 enabled 时切换启用状态。清单中没有的隧道默认保留，指定 --prune 时删除。
+locations、host_header_rewrite、header_x_from_where 未填写时沿用隧道现有的值。
+--dry-run 只显示将要进行的操作。
+
+清单可以用 hayfrp proxy export 从现有隧道导出。格式（YAML）:
+  tunnels:
+    - name: web
+      type: http
+      local_port: 8080
+      node: "3"
+      domain: www.example.com
+    - name: ssh
+      type: tcp
+      local_ip: 127.0.0.1
+      local_port: 22
+      remote_port: 20022
+      node: "3"
+      encryption: true
+      compression: true
+      enabled: false`,
+	Example: `  hayfrp apply -f tunnels.yaml --dry-run
+  hayfrp apply -f tunnels.toml --prune`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, _ := cmd.Flags().GetString("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		manifest, err := readTunnelManifest(file, cmd.InOrStdin())
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		ctx := cmd.Context()
+		client := newProxyClient()
+		listResp, err := client.ListTunnelContext(ctx, csrf, "")
+		if err != nil {
+			fmt.Printf("✗ 获取隧道列表失败: %v\n", err)
+			return
+		}
+
+		steps, unmanaged := planTunnels(listResp.Proxies, manifest, prune)
+		for _, step := range steps {
+			fmt.Println(step)
+		}
+		if len(unmanaged) > 0 {
+			names := make([]string, len(unmanaged))
+			for i, t := range unmanaged {
+				names[i] = t.ProxyName
+			}
+			fmt.Printf("清单中没有的隧道将保留: %s（使用 --prune 删除）\n", strings.Join(names, ", "))
+		}
+		if len(steps) == 0 {
+			fmt.Println("✓ 隧道与清单一致，无需修改")
+			return
+		}
+		if dryRun {
+			fmt.Printf("\n共 %d 项操作（--dry-run，未做任何修改）\n", len(steps))
+			return
+		}
+
+		fmt.Println()
+		if failed, skipped := applyPlan(ctx, client, csrf, steps, os.Stdout); failed > 0 {
+			if skipped > 0 {
+				fmt.Printf("\n✗ %d 项操作失败，%d 项因此跳过，修正后可重新执行 apply\n", failed, skipped)
+			} else {
+				fmt.Printf("\n✗ %d 项操作失败，修正后可重新执行 apply\n", failed)
+			}
+			return
+		}
+		fmt.Printf("\n✓ 已完成 %d 项操作\n", len(steps))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringP("file", "f", "", "清单文件路径（.yaml/.yml/.json/.toml，- 表示从标准输入读取 YAML）")
+	applyCmd.Flags().Bool("dry-run", false, "只显示将要进行的操作")
+	applyCmd.Flags().Bool("prune", false, "删除清单中没有的隧道")
+	applyCmd.MarkFlagRequired("file")
+}
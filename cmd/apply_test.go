@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"hayfrp-cli/api"
+)
+
+// existingTunnel 与 sshSpec 一致的已有隧道
+var existingTunnel = api.TunnelInfo{
+	ID:             "12",
+	ProxyName:      "ssh",
+	ProxyType:      "tcp",
+	LocalIP:        "127.0.0.1",
+	LocalPort:      "22",
+	RemotePort:     "20022",
+	Node:           "3",
+	UseEncryption:  "1",
+	UseCompression: "false",
+	Locations:      "/",
+	Status:         "true",
+}
+
+func sshSpec() tunnelSpec {
+	return tunnelSpec{
+		Name:       "ssh",
+		Type:       "tcp",
+		LocalIP:    "127.0.0.1",
+		LocalPort:  22,
+		RemotePort: 20022,
+		Node:       "3",
+		Encryption: true,
+	}
+}
+
+func TestDiffTunnel(t *testing.T) {
+	if changes := diffTunnel(existingTunnel, sshSpec()); len(changes) != 0 {
+		t.Errorf("一致的隧道不应有变更: %v", changes)
+	}
+
+	s := sshSpec()
+	s.LocalPort = 2222
+	s.Encryption = false
+	s.HostHeaderRewrite = "example.com"
+	changes := diffTunnel(existingTunnel, s)
+	want := []tunnelChange{
+		{"local_port", "22", "2222"},
+		{"encryption", "true", "false"},
+		{"host_header_rewrite", "", "example.com"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("diffTunnel = %v，期望 %v", changes, want)
+	}
+
+	// 接口中未设置的远程端口为空字符串
+	noPort := existingTunnel
+	noPort.RemotePort = ""
+	s = sshSpec()
+	s.RemotePort = 0
+	if changes := diffTunnel(noPort, s); len(changes) != 0 {
+		t.Errorf("空端口与 0 应视为一致: %v", changes)
+	}
+}
+
+// actions 返回计划中各步骤的操作与隧道名称
+func actions(steps []planStep) []string {
+	var got []string
+	for _, s := range steps {
+		got = append(got, s.summary())
+	}
+	return got
+}
+
+func TestPlanTunnels(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	web := tunnelSpec{Name: "web", Type: "http", LocalIP: "127.0.0.1", LocalPort: 8080, Node: "3", Domain: "a.example.com", Enabled: &disabled}
+	ssh := sshSpec()
+	ssh.LocalPort = 2222
+	ssh.Enabled = &enabled
+	old := api.TunnelInfo{ID: "7", ProxyName: "old", Status: "true"}
+	off := existingTunnel
+	off.Status = "false"
+
+	manifest := &tunnelManifest{Tunnels: []tunnelSpec{web, ssh}}
+
+	steps, unmanaged := planTunnels([]api.TunnelInfo{off, old}, manifest, true)
+	want := []string{"删除 old", "创建 web", "禁用 web", "修改 ssh", "启用 ssh"}
+	if got := actions(steps); !slices.Equal(got, want) {
+		t.Errorf("planTunnels(prune) = %v，期望 %v", got, want)
+	}
+	if len(unmanaged) != 0 {
+		t.Errorf("prune 时不应有未管理的隧道: %v", unmanaged)
+	}
+	if steps[2].Current.ID != "" || steps[2].Enable {
+		t.Errorf("新建隧道的禁用操作 = %+v", steps[2])
+	}
+
+	steps, unmanaged = planTunnels([]api.TunnelInfo{off, old}, manifest, false)
+	if got := actions(steps); slices.Contains(got, "删除 old") {
+		t.Errorf("未指定 prune 时不应删除: %v", got)
+	}
+	if len(unmanaged) != 1 || unmanaged[0].ProxyName != "old" {
+		t.Errorf("unmanaged = %v，期望 [old]", unmanaged)
+	}
+}
+
+func TestPlanTunnelsNoChanges(t *testing.T) {
+	steps, unmanaged := planTunnels([]api.TunnelInfo{existingTunnel}, &tunnelManifest{Tunnels: []tunnelSpec{sshSpec()}}, true)
+	if len(steps) != 0 || len(unmanaged) != 0 {
+		t.Errorf("一致时不应有操作: %v %v", actions(steps), unmanaged)
+	}
+}
+
+func TestApplyPlanSkipsToggleAfterFailedCreate(t *testing.T) {
+	var types []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		types = append(types, fmt.Sprint(req["type"], ":", req["proxy_name"]))
+		w.Header().Set("Content-Type", "application/json")
+		if req["proxy_name"] == "web" {
+			json.NewEncoder(w).Encode(map[string]any{"status": 403, "message": "域名已被占用"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": 200, "id": "20"})
+	}))
+	defer srv.Close()
+
+	client := api.NewProxyAPIClient(api.WithEndpoints(srv.URL), api.WithRetryPolicy(api.NoRetry))
+	disabled := false
+	web := tunnelSpec{Name: "web", Type: "http", LocalIP: "127.0.0.1", LocalPort: 8080, Node: "3", Domain: "a.example.com", Enabled: &disabled}
+	ssh := sshSpec()
+	steps, _ := planTunnels(nil, &tunnelManifest{Tunnels: []tunnelSpec{web, ssh}}, false)
+
+	var out strings.Builder
+	failed, skipped := applyPlan(context.Background(), client, "csrf", steps, &out)
+	if failed != 1 || skipped != 1 {
+		t.Errorf("failed=%d skipped=%d，期望 1 1\n%s", failed, skipped, out.String())
+	}
+	// 切换状态的请求没有 proxy_name，跳过时不应发出
+	if want := []string{"add:web", "add:ssh"}; !slices.Equal(types, want) {
+		t.Errorf("请求 %v，期望 %v", types, want)
+	}
+	if !strings.Contains(out.String(), "跳过禁用 web") {
+		t.Errorf("输出中没有跳过提示:\n%s", out.String())
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	tunnels := []api.TunnelInfo{
+		existingTunnel,
+		{
+			ID:                "13",
+			ProxyName:         "web",
+			ProxyType:         "http",
+			LocalIP:           "127.0.0.1",
+			LocalPort:         "8080",
+			Node:              "3",
+			Domain:            "a.example.com",
+			UseEncryption:     "false",
+			UseCompression:    "true",
+			HostHeaderRewrite: "backend.local",
+			Status:            "false",
+			LastUpdate:        "1700000000",
+			NodeDomain:        "node3.example.com",
+		},
+	}
+
+	manifest := &tunnelManifest{}
+	for _, info := range tunnels {
+		spec, err := tunnelSpecFromInfo(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest.Tunnels = append(manifest.Tunnels, spec)
+	}
+
+	for _, format := range []string{"yaml", "json", "toml"} {
+		data, err := encodeTunnelManifest(manifest, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		path := filepath.Join(t.TempDir(), "tunnels."+format)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readTunnelManifest(path, nil)
+		if err != nil {
+			t.Fatalf("%s: 读取导出的清单失败: %v\n%s", format, err, data)
+		}
+
+		// 导入导出的清单不应产生任何操作
+		steps, unmanaged := planTunnels(tunnels, got, true)
+		if len(steps) != 0 || len(unmanaged) != 0 {
+			t.Errorf("%s: 往返后仍有操作 %v\n%s", format, actions(steps), data)
+		}
+		if strings.Contains(string(data), "1700000000") || strings.Contains(string(data), "node3.example.com") {
+			t.Errorf("%s: 导出了服务端维护的字段\n%s", format, data)
+		}
+	}
+}
+
+func TestManifestFormat(t *testing.T) {
+	tests := map[string]string{
+		"t.yaml": "yaml",
+		"t.YML":  "yaml",
+		"t.json": "json",
+		"t.toml": "toml",
+		"t.txt":  "",
+		"":       "",
+	}
+	for path, want := range tests {
+		if got := manifestFormat(path); got != want {
+			t.Errorf("manifestFormat(%q) = %q，期望 %q", path, got, want)
+		}
+	}
+}
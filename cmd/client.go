@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/viper"
+)
+
+// configureEndpoints 根据配置设置默认API端点列表
+//
+// 配置文件中使用 api.endpoints 列表，环境变量 HAYFRP_API_ENDPOINTS 使用逗号分隔，
+// 按优先级排序，第一项为主端点。
+func configureEndpoints() {
+	var endpoints []string
+	for _, item := range viper.GetStringSlice("api.endpoints") {
+		endpoints = append(endpoints, strings.Split(item, ",")...)
+	}
+	api.SetEndpoints(endpoints)
+}
+
+// apiOptions 根据配置文件生成API客户端配置
+//
+// 支持的配置项（也可通过 HAYFRP_API_* 环境变量设置）:
+//
+//	api.timeout     单次请求超时，如 10s
+//	api.proxy       HTTP 代理地址，如 http://proxy.corp:3128
+//	api.user_agent  自定义 User-Agent
+//	api.retry.max_attempts / base_delay / max_delay  查询类请求的重试策略
+func apiOptions() []api.Option {
+	var opts []api.Option
+
+	if timeout := viper.GetDuration("api.timeout"); timeout > 0 {
+		opts = append(opts, api.WithTimeout(timeout))
+	}
+
+	if proxy := viper.GetString("api.proxy"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "忽略无效的代理地址 %s: %v\n", proxy, err)
+		} else {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(proxyURL)
+			opts = append(opts, api.WithTransport(transport))
+		}
+	}
+
+	if ua := viper.GetString("api.user_agent"); ua != "" {
+		opts = append(opts, api.WithUserAgent(ua))
+	}
+
+	if viper.GetBool("log.trace") {
+		opts = append(opts, api.WithTrace(true))
+	}
+
+	if viper.IsSet("api.retry.max_attempts") || viper.IsSet("api.retry.base_delay") || viper.IsSet("api.retry.max_delay") {
+		policy := api.DefaultRetryPolicy
+		if viper.IsSet("api.retry.max_attempts") {
+			policy.MaxAttempts = viper.GetInt("api.retry.max_attempts")
+		}
+		if viper.IsSet("api.retry.base_delay") {
+			policy.BaseDelay = viper.GetDuration("api.retry.base_delay")
+		}
+		if viper.IsSet("api.retry.max_delay") {
+			policy.MaxDelay = viper.GetDuration("api.retry.max_delay")
+		}
+		opts = append(opts, api.WithRetryPolicy(policy))
+	}
+
+	return opts
+}
+
+// sessionOptions 使用保存的登录状态时，Token 过期后自动刷新并重放请求
+//
+// 通过 --token / HAYFRP_TOKEN 指定 Token 时不刷新。
+func sessionOptions() []api.Option {
+	if viper.GetString("token") != "" {
+		return nil
+	}
+	return []api.Option{api.WithTokenRefresher(sessionRefresher(currentProfile(), nil))}
+}
+
+// newUserClient 创建按配置初始化的用户API客户端，opts 追加在配置项之后
+func newUserClient(opts ...api.Option) *api.UserAPIClient {
+	return api.NewUserAPIClient(slices.Concat(apiOptions(), sessionOptions(), opts)...)
+}
+
+// newProfileUserClient 创建使用指定账户登录状态的用户API客户端
+//
+// 用于同时处理多个账户的场景，Token 刷新成功后调用 onRefresh（可为 nil）。
+func newProfileUserClient(profile string, onRefresh func(csrf string)) *api.UserAPIClient {
+	return api.NewUserAPIClient(append(apiOptions(), api.WithTokenRefresher(sessionRefresher(profile, onRefresh)))...)
+}
+
+// newProxyClient 创建按配置初始化的隧道API客户端，opts 追加在配置项之后
+func newProxyClient(opts ...api.Option) *api.ProxyAPIClient {
+	return api.NewProxyAPIClient(slices.Concat(apiOptions(), sessionOptions(), opts)...)
+}
+
+// newNodeClient 创建按配置初始化的节点API客户端
+func newNodeClient() *api.NodeAPIClient {
+	return api.NewNodeAPIClient(apiOptions()...)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "生成命令行自动补全脚本",
+	Long: `生成指定 shell 的自动补全脚本
+
+Bash:
+  $ source <(hayfrp completion bash)
+  # 永久生效 (Linux):
+  $ hayfrp completion bash > /etc/bash_completion.d/hayfrp
+
+Zsh:
+  $ hayfrp completion zsh > "${fpath[1]}/_hayfrp"
+
+Fish:
+  $ hayfrp completion fish > ~/.config/fish/completions/hayfrp.fish
+
+PowerShell:
+  PS> hayfrp completion powershell | Out-String | Invoke-Expression`,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
@@ -0,0 +1,746 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hayfrp-cli/supervisor"
+
+	"github.com/spf13/cobra"
+)
+
+// daemonLogLimit 日志文件超过该大小时轮转为 .1，只保留一份旧日志
+const daemonLogLimit = 10 << 20
+
+func daemonSocketPath() string { return filepath.Join(daemonRunDir(), "daemon.sock") }
+func daemonPidFile() string    { return filepath.Join(hayfrpDir(), "daemon.pid") }
+func daemonStateFile() string  { return filepath.Join(hayfrpDir(), "daemon.json") }
+func daemonLogDir() string     { return filepath.Join(hayfrpDir(), "logs") }
+
+// daemonRunDir 存放控制 socket 的目录，只允许当前用户访问
+func daemonRunDir() string { return filepath.Join(hayfrpDir(), "run") }
+
+// listenDaemonSocket 在只允许当前用户访问的目录中监听控制 socket
+//
+// 目录权限保证 socket 创建后、chmod 之前其他用户也无法连接，
+// 不需要修改进程级别的 umask（会影响其他协程同时创建的文件）。
+func listenDaemonSocket(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// 目录已存在时 MkdirAll 不会修改权限
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// daemonLogFile 返回守护进程中一组隧道的 frpc 日志文件
+func daemonLogFile(id string) string {
+	return filepath.Join(daemonLogDir(), strings.ReplaceAll(id, "@", "_")+".log")
+}
+
+// tunnelRef 隧道ID与名称
+type tunnelRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// daemonTunnel 守护进程中由一个 frpc 运行的一组隧道，保存在 daemon.json 中，
+// 守护进程重新启动后按其恢复运行
+type daemonTunnel struct {
+	ID       string      `json:"id"`
+	Profile  string      `json:"profile"`
+	Node     string      `json:"node"`
+	NodeName string      `json:"node_name"`
+	Tunnels  []tunnelRef `json:"tunnels"`
+	File     string      `json:"file"`
+}
+
+// newDaemonTunnel 根据 prepareTunnels 生成的隧道组创建守护进程条目
+//
+// 条目ID为配置文件名（如 node-3、tunnel-12），非默认账户追加 @账户名。
+func newDaemonTunnel(profile string, group tunnelGroup) daemonTunnel {
+	id := strings.TrimSuffix(filepath.Base(group.File), filepath.Ext(group.File))
+	if profile != defaultProfile {
+		id += "@" + profile
+	}
+	t := daemonTunnel{
+		ID:       id,
+		Profile:  profile,
+		Node:     group.Node,
+		NodeName: group.NodeName,
+		File:     group.File,
+	}
+	for _, tunnel := range group.Tunnels {
+		t.Tunnels = append(t.Tunnels, tunnelRef{ID: tunnel.ID, Name: tunnel.ProxyName})
+	}
+	return t
+}
+
+// matches 判断 key 是否指向该条目：条目ID、隧道ID或隧道名称
+func (t daemonTunnel) matches(key string) bool {
+	if t.ID == key {
+		return true
+	}
+	return slices.ContainsFunc(t.Tunnels, func(r tunnelRef) bool {
+		return r.ID == key || r.Name == key
+	})
+}
+
+// tunnelNames 返回组内隧道名称，以逗号分隔
+func (t daemonTunnel) tunnelNames() string {
+	names := make([]string, len(t.Tunnels))
+	for i, r := range t.Tunnels {
+		names[i] = r.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// daemonTunnelStatus hayfrp ps 显示的条目状态
+type daemonTunnelStatus struct {
+	daemonTunnel
+	State     supervisor.State `json:"state"`
+	PID       int              `json:"pid,omitempty"`
+	Restarts  int              `json:"restarts"`
+	StartedAt time.Time        `json:"started_at,omitzero"`
+	LastExit  string           `json:"last_exit,omitempty"`
+}
+
+// readDaemonState 读取守护进程保存的隧道列表
+func readDaemonState() ([]daemonTunnel, error) {
+	data, err := os.ReadFile(daemonStateFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tunnels []daemonTunnel
+	if err := json.Unmarshal(data, &tunnels); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", daemonStateFile(), err)
+	}
+	return tunnels, nil
+}
+
+// errTunnelRunning 条目已在守护进程中运行
+var errTunnelRunning = errors.New("已在运行")
+
+// daemonEntry 守护进程中正在托管的一组隧道
+type daemonEntry struct {
+	spec   daemonTunnel
+	frpc   *supervisor.Supervisor
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// daemon 在后台托管 frpc 进程，通过 Unix Socket 接收 ps/stop/restart 等控制命令
+type daemon struct {
+	ctx      context.Context
+	frpcPath string
+
+	mu       sync.Mutex
+	entries  map[string]*daemonEntry
+	order    []string                      // 条目的启动顺序
+	watchers map[string]context.CancelFunc // 账户 -> 流量监控
+}
+
+// start 启动一组隧道，同ID的条目已停止时替换
+func (d *daemon) start(spec daemonTunnel) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if old, ok := d.entries[spec.ID]; ok {
+		select {
+		case <-old.done:
+		default:
+			return fmt.Errorf("%s %w", spec.ID, errTunnelRunning)
+		}
+	}
+	if _, err := os.Stat(spec.File); err != nil {
+		return fmt.Errorf("%s 的配置文件不可用: %w", spec.ID, err)
+	}
+
+	if err := os.MkdirAll(daemonLogDir(), 0755); err != nil {
+		return err
+	}
+	logFile, err := openRotatingFile(daemonLogFile(spec.ID), daemonLogLimit)
+	if err != nil {
+		return err
+	}
+
+	frpc := supervisor.New(d.frpcPath, []string{"-c", spec.File}, supervisorOptions(
+		supervisor.WithOutput(logFile, logFile),
+		supervisor.WithEventHandler(frpcEventLogger(logFile)),
+	)...)
+	ctx, cancel := context.WithCancel(d.ctx)
+	entry := &daemonEntry{spec: spec, frpc: frpc, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(entry.done)
+		defer logFile.Close()
+		if err := frpc.Run(ctx); err != nil {
+			logger.Warn("frpc 已停止", "id", spec.ID, "error", err)
+		}
+	}()
+
+	if _, ok := d.entries[spec.ID]; !ok {
+		d.order = append(d.order, spec.ID)
+	}
+	d.entries[spec.ID] = entry
+	d.watchTraffic(spec.Profile)
+	logger.Info("已启动隧道", "id", spec.ID, "tunnels", spec.tunnelNames())
+	return nil
+}
+
+// rotatingFile 写入时按大小轮转的日志文件
+//
+// frpc 长时间运行时日志持续增长，每次写入前检查大小，超过上限后
+// 将当前文件重命名为 .1 并重新创建。
+type rotatingFile struct {
+	mu    sync.Mutex
+	path  string
+	limit int64
+	file  *os.File
+	size  int64
+}
+
+// openRotatingFile 以追加方式打开日志文件，已超过上限时先轮转
+func openRotatingFile(path string, limit int64) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, limit: limit}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	if r.size > limit {
+		if err := r.rotate(); err != nil {
+			r.file.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// rotate 将当前文件重命名为 .1 并重新打开，调用方需持有锁或独占 r
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	renameErr := os.Rename(r.path, r.path+".1")
+	if err := r.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		// 无法轮转时继续写入原文件，再写入 limit 字节后重试
+		logger.Warn("轮转日志失败", "path", r.path, "error", renameErr)
+		r.size = 0
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.limit {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// watchTraffic 账户有隧道在运行时监控其流量，调用方需持有 d.mu
+func (d *daemon) watchTraffic(profile string) {
+	if _, ok := d.watchers[profile]; ok {
+		return
+	}
+	if session := readSessionFile(sessionFile(profile)); session == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.watchers[profile] = cancel
+	go watchTraffic(ctx, profile)
+}
+
+// resolve 查找 key 对应的条目ID，key 可以是条目ID、隧道ID或隧道名称
+func (d *daemon) resolve(key string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	specs := make([]daemonTunnel, 0, len(d.order))
+	for _, id := range d.order {
+		specs = append(specs, d.entries[id].spec)
+	}
+	spec, err := findDaemonTunnel(specs, key)
+	if err != nil {
+		return "", err
+	}
+	return spec.ID, nil
+}
+
+// halt 停止条目的 frpc 并等待其退出
+func (d *daemon) halt(id string) (daemonTunnel, error) {
+	d.mu.Lock()
+	entry, ok := d.entries[id]
+	d.mu.Unlock()
+	if !ok {
+		return daemonTunnel{}, fmt.Errorf("未找到 %s", id)
+	}
+	entry.cancel()
+	<-entry.done
+	return entry.spec, nil
+}
+
+// stop 停止并移除条目
+func (d *daemon) stop(id string) error {
+	if _, err := d.halt(id); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	delete(d.entries, id)
+	d.order = slices.DeleteFunc(d.order, func(s string) bool { return s == id })
+	d.stopIdleWatchers()
+	d.mu.Unlock()
+	return d.saveState()
+}
+
+// stopIdleWatchers 停止已没有隧道运行的账户的流量监控，调用方需持有 d.mu
+func (d *daemon) stopIdleWatchers() {
+	for profile, cancel := range d.watchers {
+		active := false
+		for _, entry := range d.entries {
+			if entry.spec.Profile == profile {
+				active = true
+				break
+			}
+		}
+		if !active {
+			cancel()
+			delete(d.watchers, profile)
+		}
+	}
+}
+
+// restart 重新启动条目的 frpc，已因崩溃停止重启的条目也可以重新启动
+func (d *daemon) restart(id string) error {
+	spec, err := d.halt(id)
+	if err != nil {
+		return err
+	}
+	return d.start(spec)
+}
+
+// list 返回全部条目的状态
+func (d *daemon) list() []daemonTunnelStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	statuses := make([]daemonTunnelStatus, 0, len(d.order))
+	for _, id := range d.order {
+		entry := d.entries[id]
+		st := entry.frpc.Status()
+		status := daemonTunnelStatus{
+			daemonTunnel: entry.spec,
+			State:        st.State,
+			PID:          st.PID,
+			Restarts:     st.Restarts,
+			StartedAt:    st.StartedAt,
+		}
+		if st.LastExit != nil {
+			status.LastExit = st.LastExit.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// saveState 保存当前的条目，守护进程重新启动后恢复
+func (d *daemon) saveState() error {
+	d.mu.Lock()
+	specs := make([]daemonTunnel, 0, len(d.order))
+	for _, id := range d.order {
+		specs = append(specs, d.entries[id].spec)
+	}
+	d.mu.Unlock()
+
+	data, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(daemonStateFile(), data, 0600)
+}
+
+// stopAll 停止全部 frpc 并等待其退出，保留条目以便下次启动时恢复
+func (d *daemon) stopAll() {
+	d.mu.Lock()
+	entries := make([]*daemonEntry, 0, len(d.entries))
+	for _, entry := range d.entries {
+		entries = append(entries, entry)
+	}
+	d.mu.Unlock()
+	for _, entry := range entries {
+		entry.cancel()
+	}
+	for _, entry := range entries {
+		<-entry.done
+	}
+}
+
+// frpcEventLogger 将 frpc 的运行状态变化写入日志文件
+func frpcEventLogger(w io.Writer) func(supervisor.Event) {
+	return func(e supervisor.Event) {
+		now := time.Now().Format(time.DateTime)
+		switch e.Type {
+		case supervisor.EventStarted:
+			fmt.Fprintf(w, "[hayfrp %s] frpc 已启动 (PID %d，已重启 %d 次)\n", now, e.PID, e.Restarts)
+		case supervisor.EventExited:
+			fmt.Fprintf(w, "[hayfrp %s] frpc 意外退出 (运行了 %s): %v\n", now, e.Uptime.Round(time.Second), e.Err)
+		case supervisor.EventRestarting:
+			fmt.Fprintf(w, "[hayfrp %s] %s 后自动重启\n", now, e.Delay.Round(100*time.Millisecond))
+		case supervisor.EventGaveUp:
+			fmt.Fprintf(w, "[hayfrp %s] 停止重启: %v\n", now, e.Err)
+		case supervisor.EventStopped:
+			fmt.Fprintf(w, "[hayfrp %s] frpc 已停止\n", now)
+		}
+	}
+}
+
+// daemonRequestBody 控制命令的请求体
+type daemonRequestBody struct {
+	ID      string         `json:"id,omitempty"`
+	Tunnels []daemonTunnel `json:"tunnels,omitempty"`
+}
+
+// daemonStartResponse /start 的响应，已在运行的条目不会重新启动
+type daemonStartResponse struct {
+	Started []string `json:"started"`
+	Running []string `json:"running"`
+}
+
+// handler 返回控制接口
+//
+//	GET  /ps        条目状态
+//	POST /start     启动隧道组 {"tunnels": [...]}
+//	POST /stop      停止并移除条目 {"id": "..."}
+//	POST /restart   重新启动条目 {"id": "..."}
+//	POST /shutdown  停止守护进程，保留条目以便下次启动时恢复
+func (d *daemon) handler(shutdown func()) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ps", func(w http.ResponseWriter, r *http.Request) {
+		writeDaemonJSON(w, http.StatusOK, d.list())
+	})
+	mux.HandleFunc("POST /start", func(w http.ResponseWriter, r *http.Request) {
+		var body daemonRequestBody
+		if !readDaemonJSON(w, r, &body) {
+			return
+		}
+		var resp daemonStartResponse
+		var errs []error
+		for _, spec := range body.Tunnels {
+			err := d.start(spec)
+			switch {
+			case err == nil:
+				resp.Started = append(resp.Started, spec.ID)
+			case errors.Is(err, errTunnelRunning):
+				resp.Running = append(resp.Running, spec.ID)
+			default:
+				errs = append(errs, err)
+			}
+		}
+		if err := d.saveState(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := errors.Join(errs...); err != nil {
+			writeDaemonError(w, http.StatusConflict, err)
+			return
+		}
+		writeDaemonJSON(w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		d.control(w, r, d.stop)
+	})
+	mux.HandleFunc("POST /restart", func(w http.ResponseWriter, r *http.Request) {
+		d.control(w, r, d.restart)
+	})
+	mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, r *http.Request) {
+		// 先停止全部 frpc 再响应，客户端收到响应时隧道已经停止
+		d.stopAll()
+		writeDaemonJSON(w, http.StatusOK, struct{}{})
+		http.NewResponseController(w).Flush()
+		shutdown()
+	})
+	return mux
+}
+
+// control 处理以条目ID为参数的控制命令
+func (d *daemon) control(w http.ResponseWriter, r *http.Request, fn func(id string) error) {
+	var body daemonRequestBody
+	if !readDaemonJSON(w, r, &body) {
+		return
+	}
+	id, err := d.resolve(body.ID)
+	if err != nil {
+		writeDaemonError(w, http.StatusNotFound, err)
+		return
+	}
+	if err := fn(id); err != nil {
+		writeDaemonError(w, http.StatusConflict, err)
+		return
+	}
+	writeDaemonJSON(w, http.StatusOK, struct {
+		ID string `json:"id"`
+	}{id})
+}
+
+func readDaemonJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeDaemonError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+func writeDaemonJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeDaemonError(w http.ResponseWriter, status int, err error) {
+	writeDaemonJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// runDaemon 在前台运行守护进程，直到 ctx 被取消或收到 shutdown 命令
+func runDaemon(ctx context.Context) error {
+	if err := os.MkdirAll(hayfrpDir(), 0755); err != nil {
+		return err
+	}
+	if pingDaemon(ctx) == nil {
+		return errors.New("守护进程已在运行")
+	}
+
+	frpcPath := locateFrpc()
+	if frpcPath == "" {
+		var err error
+		frpcPath, err = downloadFrpc(ctx, hayfrpDir())
+		if err != nil {
+			return fmt.Errorf("未找到 frpc，自动下载失败: %w", err)
+		}
+	}
+
+	// 上次异常退出时残留的 socket，正常退出时 listener 关闭后自动删除
+	socketPath := daemonSocketPath()
+	os.Remove(socketPath)
+	listener, err := listenDaemonSocket(socketPath)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", socketPath, err)
+	}
+
+	pid := strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(daemonPidFile(), []byte(pid), 0644); err != nil {
+		listener.Close()
+		return err
+	}
+	// PID 文件最后删除，daemon stop 以此判断守护进程已完全退出
+	defer func() {
+		if data, err := os.ReadFile(daemonPidFile()); err == nil && string(data) == pid {
+			os.Remove(daemonPidFile())
+		}
+	}()
+
+	ctx, shutdown := context.WithCancel(ctx)
+	defer shutdown()
+	d := &daemon{
+		ctx:      ctx,
+		frpcPath: frpcPath,
+		entries:  make(map[string]*daemonEntry),
+		watchers: make(map[string]context.CancelFunc),
+	}
+
+	// 恢复上次运行的隧道
+	specs, err := readDaemonState()
+	if err != nil {
+		logger.Warn("读取守护进程状态失败", "error", err)
+	}
+	for _, spec := range specs {
+		if err := d.start(spec); err != nil {
+			fmt.Printf("✗ 恢复 %s 失败: %v\n", spec.ID, err)
+		}
+	}
+	if len(specs) > 0 {
+		d.saveState()
+	}
+
+	server := &http.Server{Handler: d.handler(shutdown)}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	fmt.Printf("[%s] 守护进程已启动 (PID %d)，使用 frpc: %s，恢复了 %d 组隧道\n",
+		time.Now().Format(time.DateTime), os.Getpid(), frpcPath, len(d.list()))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	d.stopAll()
+	fmt.Printf("[%s] 守护进程已停止\n", time.Now().Format(time.DateTime))
+	return nil
+}
+
+// daemonForwardedFlags 返回需要传给后台守护进程的全局参数
+//
+// --token 不传递，避免 Token 出现在进程列表中；守护进程按各条目的账户读取保存的登录状态。
+func daemonForwardedFlags() []string {
+	var args []string
+	for _, name := range []string{"config", "profile", "verbose", "log-level", "trace"} {
+		if f := rootCmd.PersistentFlags().Lookup(name); f != nil && f.Changed {
+			args = append(args, "--"+name+"="+f.Value.String())
+		}
+	}
+	return args
+}
+
+// startDaemonDetached 在后台启动守护进程并等待其就绪
+func startDaemonDetached(ctx context.Context) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hayfrpDir(), 0755); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(hayfrpDir(), "daemon.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	args := append([]string{"daemon"}, daemonForwardedFlags()...)
+	c := exec.Command(exe, args...)
+	c.Stdout = logFile
+	c.Stderr = logFile
+	c.SysProcAttr = detachedProcAttr()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- c.Wait() }()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待守护进程启动超时，详见 %s", logFile.Name())
+		case err := <-exited:
+			return fmt.Errorf("守护进程启动失败 (%v)，详见 %s", err, logFile.Name())
+		case <-ticker.C:
+			if pingDaemon(ctx) == nil {
+				c.Process.Release()
+				return nil
+			}
+		}
+	}
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "在后台托管运行隧道",
+	Long: `守护进程在后台托管 frpc，关闭终端后隧道继续运行。
+
+hayfrp up -d 将隧道交给守护进程运行（守护进程未运行时自动在后台启动），
+hayfrp ps / stop / restart / logs 通过 ~/.hayfrp/run/daemon.sock 查看和控制。
+守护进程运行的隧道记录在 ~/.hayfrp/daemon.json 中，重新启动后自动恢复。
+
+不带参数时在前台运行，适合由 systemd 等服务管理器启动；-d 在后台运行。`,
+	Example: `  hayfrp daemon -d
+  hayfrp daemon stop`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			if err := startDaemonDetached(cmd.Context()); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			fmt.Println("✓ 守护进程已在后台启动")
+			return
+		}
+		if err := runDaemon(cmd.Context()); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "停止守护进程",
+	Long:  `停止守护进程及其运行的全部隧道，隧道列表会保留，下次启动守护进程时恢复`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := daemonCall(cmd.Context(), http.MethodPost, "/shutdown", struct{}{}, nil); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		// 等待守护进程退出，避免随后启动的守护进程与其冲突
+		for range 50 {
+			if _, err := os.Stat(daemonPidFile()); os.IsNotExist(err) {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		fmt.Println("✓ 守护进程已停止")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+
+	daemonCmd.Flags().BoolP("detach", "d", false, "在后台运行")
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frpc.log")
+	if err := os.WriteFile(path, []byte("0123456789ab"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 打开时已超过上限，先轮转
+	r, err := openRotatingFile(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	assertFile(t, path+".1", "0123456789ab")
+	assertFile(t, path, "")
+
+	for _, line := range []string{"first\n", "second\n", "x\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 写入 second 前超过上限，只保留一份旧日志
+	assertFile(t, path+".1", "first\n")
+	assertFile(t, path, "second\nx\n")
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("%s 的内容为 %q，期望 %q", filepath.Base(path), data, want)
+	}
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// errDaemonNotRunning 无法连接守护进程
+var errDaemonNotRunning = errors.New("守护进程未运行，请先执行 hayfrp daemon -d 或 hayfrp up -d")
+
+// daemonClient 通过 Unix Socket 访问守护进程的 HTTP 客户端
+var daemonClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", daemonSocketPath())
+		},
+	},
+}
+
+// daemonCall 向守护进程发送控制命令，body 为 nil 时不发送请求体，out 为 nil 时忽略响应
+func daemonCall(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://hayfrp"+path, reader)
+	if err != nil {
+		return err
+	}
+	resp, err := daemonClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errDaemonNotRunning
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("守护进程返回 %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// pingDaemon 检查守护进程是否在运行
+func pingDaemon(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	return daemonCall(ctx, http.MethodGet, "/ps", nil, nil)
+}
+
+// ensureDaemon 守护进程未运行时在后台启动
+func ensureDaemon(ctx context.Context) error {
+	if pingDaemon(ctx) == nil {
+		return nil
+	}
+	fmt.Println("正在后台启动守护进程...")
+	return startDaemonDetached(ctx)
+}
+
+// findDaemonTunnel 按条目ID、隧道ID或隧道名称查找守护进程中的条目
+func findDaemonTunnel(tunnels []daemonTunnel, key string) (daemonTunnel, error) {
+	var found []daemonTunnel
+	for _, t := range tunnels {
+		if t.ID == key {
+			return t, nil
+		}
+		if t.matches(key) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return daemonTunnel{}, fmt.Errorf("未找到 %s", key)
+	case 1:
+		return found[0], nil
+	}
+	ids := make([]string, len(found))
+	for i, t := range found {
+		ids[i] = t.ID
+	}
+	return daemonTunnel{}, fmt.Errorf("%s 对应多个条目 (%s)，请使用条目ID", key, strings.Join(ids, ", "))
+}
+
+// formatUptime 以较短的形式显示运行时长
+func formatUptime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < time.Hour:
+		return d.Round(time.Minute).String()
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "查看守护进程运行的隧道",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var statuses []daemonTunnelStatus
+		if err := daemonCall(cmd.Context(), http.MethodGet, "/ps", nil, &statuses); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		if len(statuses) == 0 {
+			fmt.Println("守护进程中暂无隧道，使用 hayfrp up -d <proxy-id...> 启动")
+			return
+		}
+
+		rows := [][]string{{"ID", "账户", "节点", "隧道", "状态", "PID", "重启", "运行时长"}}
+		for _, s := range statuses {
+			pid, uptime := "-", "-"
+			if s.PID > 0 {
+				pid = fmt.Sprint(s.PID)
+			}
+			if !s.StartedAt.IsZero() && s.PID > 0 {
+				uptime = formatUptime(time.Since(s.StartedAt))
+			}
+			rows = append(rows, []string{
+				s.ID, s.Profile, s.NodeName, s.tunnelNames(), string(s.State), pid, fmt.Sprint(s.Restarts), uptime,
+			})
+		}
+		printTable(os.Stdout, rows)
+
+		for _, s := range statuses {
+			if s.LastExit != "" && s.PID == 0 {
+				fmt.Printf("\n%s 最近一次退出: %s\n", s.ID, s.LastExit)
+			}
+		}
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop <id>",
+	Short: "停止守护进程中的隧道",
+	Long: `停止守护进程中的一组隧道并将其移出守护进程，重新启动守护进程后不再恢复。
+
+<id> 可以是 hayfrp ps 显示的ID，也可以是隧道ID或隧道名称。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := daemonCall(cmd.Context(), http.MethodPost, "/stop", daemonRequestBody{ID: args[0]}, &resp); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已停止 %s\n", resp.ID)
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <id>",
+	Short: "重新启动守护进程中的隧道",
+	Long: `重新启动守护进程中的一组隧道，因崩溃循环停止重启的隧道也可以用它恢复运行。
+
+<id> 可以是 hayfrp ps 显示的ID，也可以是隧道ID或隧道名称。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := daemonCall(cmd.Context(), http.MethodPost, "/restart", daemonRequestBody{ID: args[0]}, &resp); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已重新启动 %s\n", resp.ID)
+	},
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <id>",
+	Short: "查看守护进程中隧道的 frpc 日志",
+	Long: `查看守护进程中一组隧道的 frpc 输出，日志保存在 ~/.hayfrp/logs 下。
+
+<id> 可以是 hayfrp ps 显示的ID，也可以是隧道ID或隧道名称。守护进程未运行时也可以查看。`,
+	Example: `  hayfrp logs node-3
+  hayfrp logs 12 -n 100
+  hayfrp logs web -f`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		lines, _ := cmd.Flags().GetInt("lines")
+		follow, _ := cmd.Flags().GetBool("follow")
+
+		var tunnels []daemonTunnel
+		var statuses []daemonTunnelStatus
+		err := daemonCall(cmd.Context(), http.MethodGet, "/ps", nil, &statuses)
+		switch {
+		case err == nil:
+			for _, s := range statuses {
+				tunnels = append(tunnels, s.daemonTunnel)
+			}
+		case errors.Is(err, errDaemonNotRunning):
+			if tunnels, err = readDaemonState(); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+		default:
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		t, err := findDaemonTunnel(tunnels, args[0])
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		if err := tailFile(cmd.Context(), os.Stdout, daemonLogFile(t.ID), lines, follow); err != nil {
+			fmt.Printf("✗ %v\n", err)
+		}
+	},
+}
+
+// tailFile 输出文件的最后 n 行，follow 时继续输出新写入的内容直到 ctx 被取消
+func tailFile(ctx context.Context, w io.Writer, path string, n int, follow bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("暂无日志")
+		}
+		return err
+	}
+	defer func() { f.Close() }()
+
+	var last []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		last = append(last, scanner.Text())
+		if n >= 0 && len(last) > n {
+			last = last[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, line := range last {
+		fmt.Fprintln(w, line)
+	}
+	if !follow {
+		return nil
+	}
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// 日志轮转后重新打开
+		if info, err := os.Stat(path); err == nil && info.Size() < offset {
+			if nf, err := os.Open(path); err == nil {
+				f.Close()
+				f, offset = nf, 0
+			}
+		}
+		written, err := io.Copy(w, f)
+		if err != nil {
+			return err
+		}
+		offset += written
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().IntP("lines", "n", 50, "显示最后的行数")
+	logsCmd.Flags().BoolP("follow", "f", false, "持续输出新的日志")
+}
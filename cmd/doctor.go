@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/cobra"
+)
+
+// certWarnDays 证书剩余有效期少于该天数时给出警告
+const certWarnDays = 14
+
+// clockSkewWarn 时钟偏差超过该值时给出警告
+const clockSkewWarn = 30 * time.Second
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "诊断API端点连通性",
+	Long: `探测所有API端点的 DNS 解析、TLS 握手、证书有效期、/version 接口延迟，
+并显示登录时使用的端点与本机时钟偏差，用于排查网络不稳定的问题。
+
+每次运行命令都从主端点开始请求，故障转移只在单次命令内生效，
+因此这里不显示"当前端点"，而是显示保存的会话登录时使用的端点`,
+	Run: runDoctor,
+}
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "API端点相关操作",
+}
+
+var apiStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看API端点状态（同 hayfrp doctor）",
+	Run:   runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	endpoints := api.APIEndpoints
+
+	// 并发探测所有端点
+	reports := make([]*api.EndpointReport, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			reports[i] = api.DiagnoseEndpoint(cmd.Context(), endpoint, apiOptions()...)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	fmt.Printf("========== API 端点诊断 ==========\n")
+	loginEndpoint := ""
+	if session := readSessionFile(sessionFile(currentProfile())); session != nil {
+		loginEndpoint = session.Endpoint
+		fmt.Printf("登录端点: %s (%s)\n", session.Endpoint, session.LoginTime.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("本机时间: %s\n\n", time.Now().Format("2006-01-02 15:04:05 MST"))
+
+	healthy := 0
+	for i, r := range reports {
+		var tags []string
+		if i == 0 {
+			tags = append(tags, "主端点")
+		}
+		if r.URL == loginEndpoint {
+			tags = append(tags, "登录时使用")
+		}
+		mark := "✓"
+		if r.OK() {
+			healthy++
+		} else {
+			mark = "✗"
+		}
+		if len(tags) > 0 {
+			fmt.Printf("%s [%d] %s (%s)\n", mark, i+1, r.URL, strings.Join(tags, ", "))
+		} else {
+			fmt.Printf("%s [%d] %s\n", mark, i+1, r.URL)
+		}
+
+		if r.DNSError != nil {
+			fmt.Printf("    DNS:  失败 - %v\n", r.DNSError)
+		} else {
+			fmt.Printf("    DNS:  %s (%s)\n", strings.Join(r.Addrs, ", "), formatDuration(r.DNSTime))
+		}
+
+		switch {
+		case r.TLSError != nil:
+			fmt.Printf("    TLS:  失败 - %v\n", r.TLSError)
+		case r.TLSVersion != "":
+			fmt.Printf("    TLS:  %s (%s)\n", r.TLSVersion, formatDuration(r.TLSTime))
+			fmt.Printf("    证书: %s，颁发者 %s\n", r.CertSubject, r.CertIssuer)
+			days := int(time.Until(r.CertExpiry).Hours() / 24)
+			warn := ""
+			if days < certWarnDays {
+				warn = " ⚠ 即将过期"
+			}
+			fmt.Printf("    有效期至: %s (剩余 %d 天)%s\n", r.CertExpiry.Format("2006-01-02"), days, warn)
+		}
+
+		if r.RequestError != nil {
+			fmt.Printf("    接口: /version 失败 - %v\n", r.RequestError)
+		} else {
+			fmt.Printf("    接口: /version HTTP %d (%s)", r.HTTPStatus, formatDuration(r.Latency))
+			if r.Version != nil && r.Version.VerHayfrps != "" {
+				fmt.Printf("，HayFrps %s", r.Version.VerHayfrps)
+			}
+			fmt.Println()
+		}
+
+		if !r.ServerTime.IsZero() {
+			warn := ""
+			if r.ClockSkew > clockSkewWarn || r.ClockSkew < -clockSkewWarn {
+				warn = " ⚠ 本机时间可能不准确"
+			}
+			fmt.Printf("    时钟偏差: %+v%s\n", r.ClockSkew, warn)
+		}
+
+		fmt.Println()
+	}
+
+	fmt.Printf("可用端点: %d / %d\n", healthy, len(reports))
+}
+
+// formatDuration 以毫秒显示耗时
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.AddCommand(apiStatusCmd)
+}
@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"hayfrp-cli/api"
+	"hayfrp-cli/supervisor"
+
+	"github.com/spf13/viper"
+)
+
+// frpcPolicy 根据配置生成 frpc 的重启策略
+//
+// 支持的配置项（也可通过 HAYFRP_FRPC_* 环境变量设置）:
+//
+//	frpc.restart.max_restarts       最大重启次数，0 不重启，负数不限
+//	frpc.restart.base_delay / max_delay  重启等待时间，指数增长
+//	frpc.restart.stable_after       运行超过该时长后重置等待时间和崩溃计数
+//	frpc.restart.crash_loop_count / crash_loop_window  崩溃循环检测
+func frpcPolicy() supervisor.Policy {
+	policy := supervisor.DefaultPolicy
+	if viper.IsSet("frpc.restart.max_restarts") {
+		policy.MaxRestarts = viper.GetInt("frpc.restart.max_restarts")
+	}
+	if viper.IsSet("frpc.restart.base_delay") {
+		policy.BaseDelay = viper.GetDuration("frpc.restart.base_delay")
+	}
+	if viper.IsSet("frpc.restart.max_delay") {
+		policy.MaxDelay = viper.GetDuration("frpc.restart.max_delay")
+	}
+	if viper.IsSet("frpc.restart.stable_after") {
+		policy.StableAfter = viper.GetDuration("frpc.restart.stable_after")
+	}
+	if viper.IsSet("frpc.restart.crash_loop_count") {
+		policy.CrashLoopCount = viper.GetInt("frpc.restart.crash_loop_count")
+	}
+	if viper.IsSet("frpc.restart.crash_loop_window") {
+		policy.CrashLoopWindow = viper.GetDuration("frpc.restart.crash_loop_window")
+	}
+	return policy
+}
+
+// supervisorOptions 根据配置生成托管 frpc 的配置项，opts 追加在配置项之后
+func supervisorOptions(opts ...supervisor.Option) []supervisor.Option {
+	options := []supervisor.Option{
+		supervisor.WithPolicy(frpcPolicy()),
+		supervisor.WithLogger(logger),
+	}
+	if timeout := viper.GetDuration("frpc.stop_timeout"); timeout > 0 {
+		options = append(options, supervisor.WithStopTimeout(timeout))
+	}
+	return append(options, opts...)
+}
+
+// frpcEventPrinter 返回在终端中显示 frpc 运行状态变化的事件处理函数，label 不为空时加在每行之前
+func frpcEventPrinter(label string) func(supervisor.Event) {
+	prefix := ""
+	if label != "" {
+		prefix = "[" + label + "] "
+	}
+	return func(e supervisor.Event) {
+		switch e.Type {
+		case supervisor.EventStarted:
+			if e.Restarts > 0 {
+				fmt.Printf("✓ %sfrpc 已重新启动 (PID %d，第 %d 次重启)\n", prefix, e.PID, e.Restarts)
+			}
+		case supervisor.EventExited:
+			fmt.Printf("\n✗ %sfrpc 意外退出 (运行了 %s): %v\n", prefix, e.Uptime.Round(time.Second), e.Err)
+		case supervisor.EventRestarting:
+			fmt.Printf("  %s%s 后自动重启...\n", prefix, e.Delay.Round(100*time.Millisecond))
+		}
+	}
+}
+
+// frpcCandidates 返回查找 frpc 可执行文件的路径，按优先级排列
+func frpcCandidates() []string {
+	frpcName := "frpc"
+	if runtime.GOOS == "windows" {
+		frpcName = "frpc.exe"
+	}
+
+	paths := []string{
+		filepath.Join(".", frpcName),
+		filepath.Join(hayfrpDir(), frpcName),
+	}
+	// Unix 系统额外路径
+	if runtime.GOOS != "windows" {
+		paths = append(paths, "/usr/local/bin/frpc", "/usr/bin/frpc")
+	}
+	return paths
+}
+
+// locateFrpc 返回已存在的 frpc 可执行文件路径，未找到时返回空字符串
+func locateFrpc() string {
+	for _, path := range frpcCandidates() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// tunnelGroup 由同一个 frpc 进程运行的一组隧道
+type tunnelGroup struct {
+	Node     string
+	NodeName string
+	Tunnels  []api.TunnelInfo
+	File     string // frpc 配置文件路径
+}
+
+// label 在输出中区分不同 frpc 进程的名称
+func (g tunnelGroup) label() string {
+	if len(g.Tunnels) == 1 {
+		return g.Tunnels[0].ProxyName
+	}
+	return g.NodeName
+}
+
+// tunnelIDs 返回组内隧道的ID
+func (g tunnelGroup) tunnelIDs() []string {
+	ids := make([]string, len(g.Tunnels))
+	for i, t := range g.Tunnels {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// enableTunnels 启用选中的隧道中处于禁用状态的隧道
+func enableTunnels(ctx context.Context, client *api.ProxyAPIClient, csrf string, tunnels []api.TunnelInfo) error {
+	for _, t := range tunnels {
+		if t.Status == "true" {
+			continue
+		}
+		fmt.Printf("隧道 %s 当前状态为禁用，正在启用...\n", t.ProxyName)
+		if _, err := client.ToggleTunnelContext(ctx, csrf, t.ID, "true"); err != nil {
+			return fmt.Errorf("启用隧道 %s 失败: %w", t.ProxyName, err)
+		}
+		fmt.Printf("✓ 隧道已启用\n")
+	}
+	return nil
+}
+
+// tunnelsDir 返回账户的 frpc 配置文件目录
+func tunnelsDir(profile string) string {
+	return filepath.Join(profileDir(profile), "tunnels")
+}
+
+// prepareTunnels 为选中的隧道生成 frpc 配置文件，同一节点的隧道由一个 frpc 运行
+//
+// 选中了节点下的全部隧道时直接获取节点配置；否则分别获取各隧道的配置并合并。
+// 配置文件按节点和隧道ID命名，同时运行的多个启动器选择不同隧道时互不覆盖。
+// all 为账户的全部隧道，用于判断是否选中了节点下的全部隧道。
+func prepareTunnels(ctx context.Context, client *api.ProxyAPIClient, csrf, profile string, selected, all []api.TunnelInfo) ([]tunnelGroup, error) {
+	dir := tunnelsDir(profile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// 按节点分组，保持选择的顺序
+	var nodes []string
+	byNode := make(map[string][]api.TunnelInfo)
+	for _, t := range selected {
+		if _, ok := byNode[t.Node]; !ok {
+			nodes = append(nodes, t.Node)
+		}
+		byNode[t.Node] = append(byNode[t.Node], t)
+	}
+	nodeSize := make(map[string]int)
+	for _, t := range all {
+		nodeSize[t.Node]++
+	}
+
+	var groups []tunnelGroup
+	for _, node := range nodes {
+		tunnels := byNode[node]
+		group := tunnelGroup{Node: node, NodeName: tunnels[0].NodeName, Tunnels: tunnels}
+
+		var config string
+		switch {
+		case len(tunnels) == 1:
+			cfg, err := client.GetTunnelConfigContext(ctx, "toml", csrf, "", tunnels[0].ID)
+			if err != nil {
+				return nil, fmt.Errorf("获取隧道 %s 的配置失败: %w", tunnels[0].ProxyName, err)
+			}
+			config = cfg
+			group.File = filepath.Join(dir, "tunnel-"+tunnels[0].ID+".toml")
+		case len(tunnels) == nodeSize[node]:
+			cfg, err := client.GetTunnelConfigContext(ctx, "toml", csrf, node, "")
+			if err != nil {
+				return nil, fmt.Errorf("获取节点 %s 的配置失败: %w", group.NodeName, err)
+			}
+			config = cfg
+			group.File = filepath.Join(dir, "node-"+node+".toml")
+		default:
+			configs := make([]string, len(tunnels))
+			for i, t := range tunnels {
+				cfg, err := client.GetTunnelConfigContext(ctx, "toml", csrf, "", t.ID)
+				if err != nil {
+					return nil, fmt.Errorf("获取隧道 %s 的配置失败: %w", t.ProxyName, err)
+				}
+				configs[i] = cfg
+			}
+			merged, ok := mergeFrpcConfigs(configs)
+			if !ok {
+				// 无法合并时每个隧道单独运行一个 frpc
+				for i, t := range tunnels {
+					single := tunnelGroup{Node: node, NodeName: group.NodeName, Tunnels: []api.TunnelInfo{t}}
+					single.File = filepath.Join(dir, "tunnel-"+t.ID+".toml")
+					if err := writeFileAtomic(single.File, []byte(configs[i]), 0600); err != nil {
+						return nil, err
+					}
+					groups = append(groups, single)
+				}
+				continue
+			}
+			config = merged
+			group.File = filepath.Join(dir, "node-"+node+"-"+strings.Join(group.tunnelIDs(), "-")+".toml")
+		}
+
+		if err := writeFileAtomic(group.File, []byte(config), 0600); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// proxiesSection 匹配 frpc TOML 配置中隧道定义的开始
+var proxiesSection = regexp.MustCompile(`(?m)^[ \t]*\[\[(?:proxies|visitors)\]\]`)
+
+// mergeFrpcConfigs 合并同一节点下多个隧道的 TOML 配置
+//
+// 保留第一份配置中隧道定义之前的公共部分（服务器地址、认证等），依次拼接各配置中的隧道定义。
+// 任一配置中找不到隧道定义时返回 false。
+func mergeFrpcConfigs(configs []string) (string, bool) {
+	var b strings.Builder
+	for i, cfg := range configs {
+		loc := proxiesSection.FindStringIndex(cfg)
+		if loc == nil {
+			return "", false
+		}
+		if i == 0 {
+			b.WriteString(cfg[:loc[0]])
+		}
+		b.WriteString(strings.TrimRight(cfg[loc[0]:], "\n"))
+		b.WriteString("\n\n")
+	}
+	return b.String(), true
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，避免 frpc 读到写了一半的配置
+//
+// 临时文件名随机生成，多个进程同时写入同一文件时不会互相覆盖临时文件。
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// runTunnelGroups 为每组隧道托管运行一个 frpc，直到 ctx 被取消或全部 frpc 停止重启
+//
+// 运行多个 frpc 时每行输出前加上隧道或节点名称。
+func runTunnelGroups(ctx context.Context, frpcPath string, groups []tunnelGroup) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make([]error, len(groups))
+	)
+	for i, group := range groups {
+		label := ""
+		var stdout, stderr io.Writer = os.Stdout, os.Stderr
+		onEvent := frpcEventPrinter("")
+		// flush 输出 frpc 退出前最后一行没有换行的输出（通常是致命错误）
+		flush := func() {}
+		if len(groups) > 1 {
+			label = group.label()
+			out := newPrefixWriter(os.Stdout, &mu, "["+label+"] ")
+			errOut := newPrefixWriter(os.Stderr, &mu, "["+label+"] ")
+			stdout, stderr = out, errOut
+			flush = func() {
+				out.Flush()
+				errOut.Flush()
+			}
+			printEvent := frpcEventPrinter(label)
+			onEvent = func(e supervisor.Event) {
+				if e.Type == supervisor.EventExited || e.Type == supervisor.EventStopped {
+					flush()
+				}
+				printEvent(e)
+			}
+		}
+
+		frpc := supervisor.New(frpcPath, []string{"-c", group.File}, supervisorOptions(
+			supervisor.WithOutput(stdout, stderr),
+			supervisor.WithEventHandler(onEvent),
+		)...)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := frpc.Run(ctx)
+			flush()
+			if err != nil {
+				if len(groups) > 1 {
+					fmt.Printf("✗ [%s] frpc 已停止: %v\n", label, err)
+				}
+				err = fmt.Errorf("%s: %w", group.label(), err)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// prefixWriter 在每行输出前加上前缀，多个 prefixWriter 共用 mu 保证整行写入
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, mu: mu, prefix: prefix}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.mu.Lock()
+		_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, p.buf[:i+1])
+		p.mu.Unlock()
+		p.buf = p.buf[i+1:]
+		if err != nil {
+			return len(data), err
+		}
+	}
+	return len(data), nil
+}
+
+// Flush 写出缓冲中不以换行结尾的剩余输出，应在子进程退出、不再有写入时调用
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	_, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf)
+	p.mu.Unlock()
+	p.buf = nil
+	return err
+}
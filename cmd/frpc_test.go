@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMergeFrpcConfigs(t *testing.T) {
+	common := `serverAddr = "node1.example.com"
+serverPort = 7000
+user = "token"
+
+`
+	web := common + `[[proxies]]
+name = "web"
+type = "http"
+localPort = 80
+`
+	ssh := common + `[[proxies]]
+name = "ssh"
+type = "tcp"
+localPort = 22
+
+
+`
+	got, ok := mergeFrpcConfigs([]string{web, ssh})
+	if !ok {
+		t.Fatal("合并失败")
+	}
+	want := common + `[[proxies]]
+name = "web"
+type = "http"
+localPort = 80
+
+[[proxies]]
+name = "ssh"
+type = "tcp"
+localPort = 22
+
+`
+	if got != want {
+		t.Errorf("合并结果:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestMergeFrpcConfigsVisitors(t *testing.T) {
+	a := "serverAddr = \"a\"\n\n[[visitors]]\nname = \"v\"\n"
+	got, ok := mergeFrpcConfigs([]string{a})
+	if !ok || got != a+"\n" {
+		t.Errorf("mergeFrpcConfigs = %q, %v", got, ok)
+	}
+}
+
+func TestMergeFrpcConfigsWithoutProxies(t *testing.T) {
+	ini := "[common]\nserver_addr = a\n\n[web]\ntype = http\n"
+	toml := "serverAddr = \"a\"\n\n[[proxies]]\nname = \"web\"\n"
+	if _, ok := mergeFrpcConfigs([]string{toml, ini}); ok {
+		t.Error("没有 [[proxies]] 的配置不应合并")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "frpc.toml")
+
+	if err := writeFileAtomic(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("文件内容 %q, %v，期望 new", data, err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("文件权限 %v，期望 0600", info.Mode().Perm())
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("目录中残留临时文件: %v", entries)
+	}
+}
+
+func TestPrefixWriterFlush(t *testing.T) {
+	var out strings.Builder
+	var mu sync.Mutex
+	w := newPrefixWriter(&out, &mu, "[a] ")
+
+	w.Write([]byte("start\nfatal: "))
+	w.Write([]byte("login failed"))
+	if got, want := out.String(), "[a] start\n"; got != want {
+		t.Fatalf("Flush 前输出 %q，期望 %q", got, want)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "[a] start\n[a] fatal: login failed\n"; got != want {
+		t.Errorf("Flush 后输出 %q，期望 %q", got, want)
+	}
+	// 缓冲已清空，再次 Flush 不重复输出
+	w.Flush()
+	if got, want := out.String(), "[a] start\n[a] fatal: login failed\n"; got != want {
+		t.Errorf("重复 Flush 后输出 %q", got)
+	}
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultProfile 未指定账户时使用的账户，数据保存在 ~/.hayfrp 下
+const defaultProfile = "default"
+
+// profileNamePattern 账户名只允许字母、数字、- 和 _，避免路径穿越
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// currentProfile 返回当前使用的账户
+//
+// 优先使用 --profile 参数或 HAYFRP_PROFILE 环境变量，其次是
+// hayfrp profile use 设置的账户，都没有时为 default。
+func currentProfile() string {
+	if name := viper.GetString("profile"); name != "" {
+		return name
+	}
+	if data, err := os.ReadFile(activeProfileFile()); err == nil {
+		if name := strings.TrimSpace(string(data)); validateProfileName(name) == nil {
+			return name
+		}
+	}
+	return defaultProfile
+}
+
+// activeProfileFile 返回记录 hayfrp profile use 选择的文件路径
+func activeProfileFile() string {
+	return filepath.Join(hayfrpDir(), "active_profile")
+}
+
+// validateProfileName 检查账户名是否合法
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的账户名 %q：只能包含字母、数字、- 和 _", name)
+	}
+	return nil
+}
+
+// profileDir 返回账户的数据目录，默认账户为 ~/.hayfrp，其他账户为 ~/.hayfrp/profiles/<name>
+//
+// 目录中保存该账户的登录状态和 frpc 配置文件。
+func profileDir(name string) string {
+	if name == defaultProfile {
+		return hayfrpDir()
+	}
+	return filepath.Join(hayfrpDir(), "profiles", name)
+}
+
+// profileExists 判断账户是否已创建，默认账户始终存在
+func profileExists(name string) bool {
+	if name == defaultProfile {
+		return true
+	}
+	info, err := os.Stat(profileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// listProfiles 返回所有账户，default 排在最前
+func listProfiles() ([]string, error) {
+	profiles := []string{defaultProfile}
+
+	entries, err := os.ReadDir(filepath.Join(hayfrpDir(), "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && validateProfileName(entry.Name()) == nil && entry.Name() != defaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
+}
+
+// useProfile 切换本次运行使用的账户
+func useProfile(name string) {
+	viper.Set("profile", name)
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "多账户管理",
+	Long: `管理多个 HayFrp 账户，每个账户的登录状态和 frpc 配置文件相互独立。
+
+默认账户 default 的数据保存在 ~/.hayfrp 下，其他账户保存在 ~/.hayfrp/profiles/<name> 下。
+任意命令均可通过 --profile 或 HAYFRP_PROFILE 临时指定账户。`,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "添加账户",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := validateProfileName(name); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		if profileExists(name) {
+			fmt.Printf("✗ 账户 %s 已存在\n", name)
+			return
+		}
+
+		if err := os.MkdirAll(profileDir(name), 0755); err != nil {
+			fmt.Printf("✗ 创建账户失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✓ 已添加账户 %s\n", name)
+		fmt.Printf("  使用 hayfrp user login [username] --profile %s 登录\n", name)
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出账户",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := listProfiles()
+		if err != nil {
+			fmt.Printf("✗ 读取账户列表失败: %v\n", err)
+			return
+		}
+
+		current := currentProfile()
+		for _, name := range profiles {
+			mark := " "
+			if name == current {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\t%s\n", mark, name, describeProfile(name))
+		}
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "切换默认使用的账户",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := validateProfileName(name); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		if !profileExists(name) {
+			fmt.Printf("✗ 账户 %s 不存在，请先运行 hayfrp profile add %s\n", name, name)
+			return
+		}
+
+		if err := os.MkdirAll(hayfrpDir(), 0755); err != nil {
+			fmt.Printf("✗ 切换账户失败: %v\n", err)
+			return
+		}
+		if err := os.WriteFile(activeProfileFile(), []byte(name+"\n"), 0644); err != nil {
+			fmt.Printf("✗ 切换账户失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✓ 已切换到账户 %s\n", name)
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "删除账户及其登录状态",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := validateProfileName(name); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		if name == defaultProfile {
+			fmt.Println("✗ 不能删除默认账户，如需清除登录状态请使用 hayfrp logout")
+			return
+		}
+		if !profileExists(name) {
+			fmt.Printf("✗ 账户 %s 不存在\n", name)
+			return
+		}
+
+		// 先删除钥匙串或加密文件中的 Token 和密码，账户目录中的 session.json 记录了它们的位置
+		if err := removeSession(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("✗ 清除账户 %s 保存的登录信息失败: %v\n", name, err)
+			fmt.Println("  账户目录已保留，请检查钥匙串或加密文件后重试")
+			return
+		}
+		if err := os.RemoveAll(profileDir(name)); err != nil {
+			fmt.Printf("✗ 删除账户失败: %v\n", err)
+			return
+		}
+
+		// 删除的是当前账户时回到默认账户
+		if data, err := os.ReadFile(activeProfileFile()); err == nil && strings.TrimSpace(string(data)) == name {
+			os.Remove(activeProfileFile())
+		}
+
+		fmt.Printf("✓ 已删除账户 %s\n", name)
+	},
+}
+
+// describeProfile 返回账户的登录状态描述
+func describeProfile(name string) string {
+	session := readSessionFile(sessionFile(name))
+	if session == nil {
+		return "(未登录)"
+	}
+	return fmt.Sprintf("%s (登录于 %s)", session.Username, session.LoginTime.Local().Format("2006-01-02 15:04"))
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+}
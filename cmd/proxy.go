@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"hayfrp-cli/api"
 
@@ -16,12 +19,10 @@ var proxyCmd = &cobra.Command{
 }
 
 var addProxyCmd = &cobra.Command{
-	Use:   "add [csrf]",
+	Use:   "add",
 	Short: "添加隧道",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-
 		name, _ := cmd.Flags().GetString("name")
 		proxyType, _ := cmd.Flags().GetString("type")
 		localIP, _ := cmd.Flags().GetString("local-ip")
@@ -63,7 +64,13 @@ var addProxyCmd = &cobra.Command{
 			compressionStr = "true"
 		}
 
-		client := api.NewProxyAPIClient()
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newProxyClient()
 		req := &api.AddTunnelRequest{
 			Type:            "add",
 			Csrf:            csrf,
@@ -79,28 +86,23 @@ var addProxyCmd = &cobra.Command{
 			Domain:          domain,
 		}
 
-		resp, err := client.AddTunnel(req)
+		resp, err := client.AddTunnelContext(cmd.Context(), req)
 		if err != nil {
 			fmt.Printf("添加隧道失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-			fmt.Printf("  隧道ID: %s\n", resp.ID)
-		} else {
-			fmt.Printf("✗ 添加隧道失败: %s\n", resp.Message)
-		}
+		fmt.Printf("✓ %s\n", resp.Message)
+		fmt.Printf("  隧道ID: %s\n", resp.ID)
 	},
 }
 
 var editProxyCmd = &cobra.Command{
-	Use:   "edit [csrf] [proxy-id]",
+	Use:   "edit [proxy-id]",
 	Short: "编辑隧道",
-	Args:  cobra.ExactArgs(2),
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-		proxyID := args[1]
+		proxyID := args[0]
 
 		name, _ := cmd.Flags().GetString("name")
 		proxyType, _ := cmd.Flags().GetString("type")
@@ -142,7 +144,13 @@ var editProxyCmd = &cobra.Command{
 			compressionStr = "true"
 		}
 
-		client := api.NewProxyAPIClient()
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newProxyClient()
 		req := &api.EditTunnelRequest{
 			Type:            "edit",
 			Csrf:            csrf,
@@ -159,100 +167,96 @@ var editProxyCmd = &cobra.Command{
 			Domain:          domain,
 		}
 
-		resp, err := client.EditTunnel(req)
+		resp, err := client.EditTunnelContext(cmd.Context(), req)
 		if err != nil {
 			fmt.Printf("编辑隧道失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 编辑隧道失败: %s\n", resp.Message)
-		}
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
 var deleteProxyCmd = &cobra.Command{
-	Use:   "delete [csrf] [proxy-id]",
+	Use:   "delete [proxy-id]",
 	Short: "删除隧道",
-	Args:  cobra.ExactArgs(2),
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-		proxyID := args[1]
+		proxyID := args[0]
 
-		client := api.NewProxyAPIClient()
-		resp, err := client.DeleteTunnel(csrf, proxyID)
+		csrf, err := resolveToken()
 		if err != nil {
-			fmt.Printf("删除隧道失败: %v\n", err)
+			fmt.Printf("✗ %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 删除隧道失败: %s\n", resp.Message)
+		client := newProxyClient()
+		resp, err := client.DeleteTunnelContext(cmd.Context(), csrf, proxyID)
+		if err != nil {
+			fmt.Printf("删除隧道失败: %v\n", err)
+			return
 		}
+
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
 var listProxyCmd = &cobra.Command{
-	Use:   "list [csrf] [proxy-id]",
+	Use:   "list [proxy-id]",
 	Short: "列出隧道",
-	Args:  cobra.RangeArgs(1, 2),
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
 		proxyID := ""
-		if len(args) > 1 {
-			proxyID = args[1]
+		if len(args) > 0 {
+			proxyID = args[0]
 		}
 
-		client := api.NewProxyAPIClient()
-		resp, err := client.ListTunnel(csrf, proxyID)
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newProxyClient()
+		resp, err := client.ListTunnelContext(cmd.Context(), csrf, proxyID)
 		if err != nil {
 			fmt.Printf("列出隧道失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			if len(resp.Proxies) == 0 {
-				fmt.Println("暂无隧道")
-				return
+		if len(resp.Proxies) == 0 {
+			fmt.Println("暂无隧道")
+			return
+		}
+		for _, p := range resp.Proxies {
+			fmt.Printf("========== 隧道 ==========\n")
+			fmt.Printf("ID: %s\n", p.ID)
+			fmt.Printf("名称: %s\n", p.ProxyName)
+			fmt.Printf("类型: %s\n", p.ProxyType)
+			fmt.Printf("本地地址: %s:%s\n", p.LocalIP, p.LocalPort)
+			fmt.Printf("远程端口: %s\n", p.RemotePort)
+			fmt.Printf("节点: %s (%s)\n", p.NodeName, p.Node)
+			fmt.Printf("节点域名: %s\n", p.NodeDomain)
+			if p.Domain != "" {
+				fmt.Printf("域名: %s\n", p.Domain)
 			}
-			for _, p := range resp.Proxies {
-				fmt.Printf("========== 隧道 ==========\n")
-				fmt.Printf("ID: %s\n", p.ID)
-				fmt.Printf("名称: %s\n", p.ProxyName)
-				fmt.Printf("类型: %s\n", p.ProxyType)
-				fmt.Printf("本地地址: %s:%s\n", p.LocalIP, p.LocalPort)
-				fmt.Printf("远程端口: %s\n", p.RemotePort)
-				fmt.Printf("节点: %s (%s)\n", p.NodeName, p.Node)
-				fmt.Printf("节点域名: %s\n", p.NodeDomain)
-				if p.Domain != "" {
-					fmt.Printf("域名: %s\n", p.Domain)
-				}
-				if p.SK != "" {
-					fmt.Printf("SK密钥: %s\n", p.SK)
-				}
-				fmt.Printf("加密: %s\n", p.UseEncryption)
-				fmt.Printf("压缩: %s\n", p.UseCompression)
-				fmt.Printf("状态: %s\n", mapStatus(p.Status))
-				fmt.Printf("最后更新: %s\n", p.LastUpdate)
-				fmt.Println()
+			if p.SK != "" {
+				fmt.Printf("SK密钥: %s\n", p.SK)
 			}
-		} else {
-			fmt.Printf("✗ 列出隧道失败: %s\n", resp.Message)
+			fmt.Printf("加密: %s\n", p.UseEncryption)
+			fmt.Printf("压缩: %s\n", p.UseCompression)
+			fmt.Printf("状态: %s\n", mapStatus(p.Status))
+			fmt.Printf("最后更新: %s\n", p.LastUpdate)
+			fmt.Println()
 		}
 	},
 }
 
 var configProxyCmd = &cobra.Command{
-	Use:   "config [csrf]",
+	Use:   "config",
 	Short: "获取隧道配置文件",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-
 		format, _ := cmd.Flags().GetString("format")
 		node, _ := cmd.Flags().GetString("node")
 		proxy, _ := cmd.Flags().GetString("proxy")
@@ -266,8 +270,14 @@ var configProxyCmd = &cobra.Command{
 			return
 		}
 
-		client := api.NewProxyAPIClient()
-		config, err := client.GetTunnelConfig(format, csrf, node, proxy)
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newProxyClient()
+		config, err := client.GetTunnelConfigContext(cmd.Context(), format, csrf, node, proxy)
 		if err != nil {
 			fmt.Printf("获取配置失败: %v\n", err)
 			return
@@ -287,77 +297,84 @@ var configProxyCmd = &cobra.Command{
 }
 
 var toggleProxyCmd = &cobra.Command{
-	Use:   "toggle [csrf] [proxy-id] [true/false]",
+	Use:   "toggle [proxy-id] [true/false]",
 	Short: "切换隧道状态",
-	Args:  cobra.ExactArgs(3),
+	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-		proxyID := args[1]
-		toggle := args[2]
+		proxyID := args[0]
+		toggle := args[1]
 
 		if toggle != "true" && toggle != "false" {
 			fmt.Println("✗ 状态必须是 true 或 false")
 			return
 		}
 
-		client := api.NewProxyAPIClient()
-		resp, err := client.ToggleTunnel(csrf, proxyID, toggle)
+		csrf, err := resolveToken()
 		if err != nil {
-			fmt.Printf("切换隧道状态失败: %v\n", err)
+			fmt.Printf("✗ %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 切换隧道状态失败: %s\n", resp.Message)
+		client := newProxyClient()
+		resp, err := client.ToggleTunnelContext(cmd.Context(), csrf, proxyID, toggle)
+		if err != nil {
+			fmt.Printf("切换隧道状态失败: %v\n", err)
+			return
 		}
+
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
 var checkProxyCmd = &cobra.Command{
-	Use:   "check [csrf] [proxy-id]",
+	Use:   "check [proxy-id]",
 	Short: "检查隧道状态",
-	Args:  cobra.ExactArgs(2),
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-		proxyID := args[1]
+		proxyID := args[0]
 
-		client := api.NewProxyAPIClient()
-		resp, err := client.CheckTunnel(csrf, proxyID)
+		csrf, err := resolveToken()
 		if err != nil {
-			fmt.Printf("检查隧道状态失败: %v\n", err)
+			fmt.Printf("✗ %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s (状态: %s)\n", resp.Message, resp.OStatus)
-		} else {
-			fmt.Printf("✗ %s\n", resp.Message)
+		client := newProxyClient()
+		resp, err := client.CheckTunnelContext(cmd.Context(), csrf, proxyID)
+		if errors.Is(err, api.ErrTunnelOffline) || errors.Is(err, api.ErrNodeOffline) {
+			fmt.Printf("✗ %v (状态: offline)\n", err)
+			return
+		}
+		if err != nil {
+			fmt.Printf("检查隧道状态失败: %v\n", err)
+			return
 		}
+
+		fmt.Printf("✓ %s (状态: %s)\n", resp.Message, resp.OStatus)
 	},
 }
 
 var forceDownProxyCmd = &cobra.Command{
-	Use:   "force-down [csrf] [proxy-id]",
+	Use:   "force-down [proxy-id]",
 	Short: "强制下线隧道",
-	Args:  cobra.ExactArgs(2),
+	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
-		proxyID := args[1]
+		proxyID := args[0]
 
-		client := api.NewProxyAPIClient()
-		resp, err := client.ForceDown(csrf, proxyID)
+		csrf, err := resolveToken()
 		if err != nil {
-			fmt.Printf("强制下线隧道失败: %v\n", err)
+			fmt.Printf("✗ %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 强制下线隧道失败: %s\n", resp.Message)
+		client := newProxyClient()
+		resp, err := client.ForceDownContext(cmd.Context(), csrf, proxyID)
+		if err != nil {
+			fmt.Printf("强制下线隧道失败: %v\n", err)
+			return
 		}
+
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
@@ -368,6 +385,72 @@ func mapStatus(status string) string {
 	return "禁用"
 }
 
+var exportProxyCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出隧道清单",
+	Long: `将账户的全部隧道导出为清单文件，用于备份、迁移到其他账户，或作为 hayfrp apply 的初始清单。
+
+导出时去掉隧道ID、更新时间、节点域名等由服务端维护的字段，
+导出的清单可以直接用 hayfrp apply -f 导入。`,
+	Example: `  hayfrp proxy export > tunnels.yaml
+  hayfrp proxy export -o tunnels.toml
+  hayfrp proxy export --format json
+  hayfrp proxy export --profile old -o t.yaml && hayfrp apply -f t.yaml --profile new`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		// 未指定 --format 时按输出文件的扩展名选择格式
+		if f := manifestFormat(output); f != "" && !cmd.Flags().Changed("format") {
+			format = f
+		}
+		format = strings.ToLower(format)
+		if !slices.Contains([]string{"yaml", "yml", "json", "toml"}, format) {
+			fmt.Printf("✗ 不支持的格式 %s (yaml/json/toml)\n", format)
+			return
+		}
+
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newProxyClient()
+		resp, err := client.ListTunnelContext(cmd.Context(), csrf, "")
+		if err != nil {
+			fmt.Printf("列出隧道失败: %v\n", err)
+			return
+		}
+
+		manifest := &tunnelManifest{Tunnels: make([]tunnelSpec, 0, len(resp.Proxies))}
+		for _, t := range resp.Proxies {
+			spec, err := tunnelSpecFromInfo(t)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ 跳过隧道: %v\n", err)
+				continue
+			}
+			manifest.Tunnels = append(manifest.Tunnels, spec)
+		}
+		data, err := encodeTunnelManifest(manifest, format)
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		if output == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		// 清单中可能包含 SK 密钥
+		if err := os.WriteFile(output, data, 0600); err != nil {
+			fmt.Printf("写入文件失败: %v\n", err)
+			return
+		}
+		fmt.Printf("✓ 已导出 %d 个隧道到: %s\n", len(manifest.Tunnels), output)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(proxyCmd)
 	proxyCmd.AddCommand(addProxyCmd)
@@ -378,6 +461,7 @@ func init() {
 	proxyCmd.AddCommand(toggleProxyCmd)
 	proxyCmd.AddCommand(checkProxyCmd)
 	proxyCmd.AddCommand(forceDownProxyCmd)
+	proxyCmd.AddCommand(exportProxyCmd)
 
 	// add proxy flags
 	addProxyCmd.Flags().String("name", "", "隧道名称")
@@ -408,4 +492,8 @@ func init() {
 	configProxyCmd.Flags().String("node", "", "节点ID")
 	configProxyCmd.Flags().String("proxy", "", "隧道ID")
 	configProxyCmd.Flags().String("output", "", "输出文件路径")
+
+	// export proxy flags
+	exportProxyCmd.Flags().String("format", "yaml", "清单格式 (yaml/json/toml)，默认按 -o 的扩展名选择")
+	exportProxyCmd.Flags().StringP("output", "o", "", "输出文件路径 (默认输出到终端)")
 }
@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+
+	"hayfrp-cli/api"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -10,27 +15,64 @@ import (
 
 var cfgFile string
 
+// logger 命令行程序的日志记录器，由 configureLogging 初始化
+var logger = slog.New(slog.DiscardHandler)
+
+// stopInterrupt 释放非交互命令注册的信号监听
+var stopInterrupt context.CancelFunc = func() {}
+
 var rootCmd = &cobra.Command{
 	Use:   "hayfrp",
 	Short: "HayFrp 隧道启动器",
-	Long:  `HayFrp 隧道启动器 - 交互式启动隧道`,
+	Long: `HayFrp 隧道启动器 - 交互式启动隧道
+
+不带任何参数运行时进入交互式启动流程（等同于 hayfrp start），
+也可以通过 user / proxy / node 等子命令在脚本中管理账户与隧道。`,
+	Example: `  hayfrp                      进入交互式启动流程
+  hayfrp proxy list           列出隧道
+  hayfrp up 12 15             同时启动多个隧道
+  hayfrp up -d 12             在后台运行隧道
+  hayfrp node list            获取节点列表
+  hayfrp completion bash      生成 bash 自动补全脚本`,
+	// 非交互命令收到 Ctrl+C 时取消正在进行的请求；
+	// 交互式启动流程只在请求期间捕获信号，见 start.go
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if !cmd.HasParent() || cmd == startCmd {
+			return
+		}
+		var ctx context.Context
+		ctx, stopInterrupt = interruptContext(cmd.Context())
+		cmd.SetContext(ctx)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		stopInterrupt()
+	},
+	// 无参数时回退到交互式启动流程
+	Run: func(cmd *cobra.Command, args []string) {
+		startCmd.Run(cmd, args)
+	},
 }
 
+// Execute 解析命令行参数并执行对应命令
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
-// ExecuteStart 直接执行start命令的逻辑
-func ExecuteStart() {
-	startCmd.Run(startCmd, nil)
-}
-
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "配置文件路径 (默认为 ~/.hayfrp.yaml)")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "输出调试日志 (等同于 --log-level debug)")
+	rootCmd.PersistentFlags().String("log-level", "", "日志级别 (debug/info/warn/error)，默认不输出日志")
+	rootCmd.PersistentFlags().Bool("trace", false, "记录完整的API请求与响应 (csrf、密码等已遮盖)")
+	rootCmd.PersistentFlags().String("profile", "", "使用的账户 (默认为 hayfrp profile use 选择的账户，也可通过 HAYFRP_PROFILE 设置)")
+	rootCmd.PersistentFlags().String("token", "", "指定用户Token (默认使用已保存的登录状态，也可通过 HAYFRP_TOKEN 设置)")
+
+	viper.BindPFlag("log.level", rootCmd.PersistentFlags().Lookup("log-level"))
+	viper.BindPFlag("log.trace", rootCmd.PersistentFlags().Lookup("trace"))
+	viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+	viper.BindPFlag("token", rootCmd.PersistentFlags().Lookup("token"))
 }
 
 func initConfig() {
@@ -45,9 +87,49 @@ func initConfig() {
 		viper.SetConfigName(".hayfrp")
 	}
 
+	// 环境变量以 HAYFRP_ 为前缀，如 api.proxy 对应 HAYFRP_API_PROXY
+	viper.SetEnvPrefix("hayfrp")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("使用配置文件:", viper.ConfigFileUsed())
+	err := viper.ReadInConfig()
+	// 显式指定的配置文件必须能读取，默认配置文件不存在则忽略
+	if err != nil && cfgFile != "" {
+		cobra.CheckErr(fmt.Errorf("读取配置文件失败: %w", err))
+	}
+
+	configureLogging()
+	if err == nil {
+		logger.Info("使用配置文件", "path", viper.ConfigFileUsed())
+	}
+
+	if err := validateProfileName(currentProfile()); err != nil {
+		cobra.CheckErr(err)
+	}
+
+	configureEndpoints()
+}
+
+// configureLogging 根据 --verbose、--log-level、--trace 及配置文件设置日志输出
+//
+// 日志只写入 stderr，默认不输出，避免污染 proxy config 等命令的标准输出。
+func configureLogging() {
+	level := viper.GetString("log.level")
+	if verbose, _ := rootCmd.PersistentFlags().GetBool("verbose"); verbose || viper.GetBool("log.trace") {
+		level = "debug"
+	}
+	if level == "" {
+		logger = slog.New(slog.DiscardHandler)
+		api.SetLogger(nil)
+		return
 	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		fmt.Fprintf(os.Stderr, "忽略无效的日志级别: %s\n", level)
+		l = slog.LevelInfo
+	}
+
+	logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l}))
+	api.SetLogger(logger)
 }
@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// secretService 在系统钥匙串中登记的服务名
+const secretService = "hayfrp-cli"
+
+// errSecretNotFound 存储中没有对应的密钥
+var errSecretNotFound = errors.New("未找到保存的密钥")
+
+// secretStore 保存 Token 等敏感信息的后端
+type secretStore interface {
+	// Name 返回后端名称，记录在 session.json 中，读取时使用同一后端
+	Name() string
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// secretStoreByName 按名称返回存储后端，未知名称返回 nil
+func secretStoreByName(name string) secretStore {
+	switch name {
+	case "secret-service":
+		return secretToolStore{}
+	case "keychain":
+		return keychainStore{}
+	case "file":
+		return defaultFileStore()
+	}
+	return nil
+}
+
+// defaultSecretStore 根据配置 secret.store 选择存储后端
+//
+// auto（默认）优先使用系统钥匙串（Linux 的 Secret Service、macOS 的钥匙串），
+// 不可用时使用加密文件 ~/.hayfrp/secrets.enc。
+func defaultSecretStore() secretStore {
+	switch mode := viper.GetString("secret.store"); mode {
+	case "", "auto", "keyring":
+		if store := systemKeyring(); store != nil {
+			return store
+		}
+		if mode == "keyring" {
+			logger.Warn("系统钥匙串不可用，改用加密文件保存Token")
+		}
+	case "file":
+	default:
+		logger.Warn("未知的 secret.store 配置，改用加密文件保存Token", "store", mode)
+	}
+	return defaultFileStore()
+}
+
+// systemKeyring 返回当前系统可用的钥匙串，没有时返回 nil
+func systemKeyring() secretStore {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// secret-tool 需要图形会话中的 D-Bus，无头服务器上通常不可用
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil
+		}
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretToolStore{}
+		}
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return keychainStore{}
+		}
+	}
+	return nil
+}
+
+// secretToolStore 通过 libsecret 的 secret-tool 访问 Secret Service（GNOME Keyring、KWallet 等）
+type secretToolStore struct{}
+
+func (secretToolStore) Name() string { return "secret-service" }
+
+func (secretToolStore) Get(key string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", secretService, "account", key).Output()
+	if err != nil {
+		// 条目不存在时 secret-tool 以非零状态退出且没有输出
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(out) == 0 {
+			return "", errSecretNotFound
+		}
+		return "", fmt.Errorf("secret-tool: %w", err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func (secretToolStore) Set(key, value string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=HayFrp "+key, "service", secretService, "account", key)
+	// 通过标准输入传递，避免出现在进程参数中
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (secretToolStore) Delete(key string) error {
+	// 条目不存在也视为成功
+	exec.Command("secret-tool", "clear", "service", secretService, "account", key).Run()
+	return nil
+}
+
+// keychainStore 通过 security 命令访问 macOS 钥匙串
+type keychainStore struct{}
+
+func (keychainStore) Name() string { return "keychain" }
+
+func (keychainStore) Get(key string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", secretService, "-a", key, "-w").Output()
+	if err != nil {
+		// 44: errSecItemNotFound
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", errSecretNotFound
+		}
+		return "", fmt.Errorf("security: %w", err)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func (keychainStore) Set(key, value string) error {
+	// security 的 -w 参数会让密钥出现在进程参数中，其他用户可通过 ps 看到；
+	// 改为以交互模式 (-i) 从标准输入读取命令，-X 以十六进制传递密钥，无需处理引号转义。
+	// -U 已存在时更新；key 为账户名拼接的固定格式，不含空白与引号。
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -l \"HayFrp %s\" -X %s\n",
+		secretService, key, key, hex.EncodeToString([]byte(value))))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("security: %w: %s", err, bytes.TrimSpace(out))
+	}
+	// 交互模式下子命令失败时 security 仍以 0 退出，成功时除提示符外没有输出
+	if msg := strings.TrimSpace(strings.ReplaceAll(string(out), "security> ", "")); msg != "" {
+		return fmt.Errorf("security: %s", msg)
+	}
+	return nil
+}
+
+func (keychainStore) Delete(key string) error {
+	exec.Command("security", "delete-generic-password", "-s", secretService, "-a", key).Run()
+	return nil
+}
+
+// fileStore 使用 AES-256-GCM 加密的本地文件保存密钥
+//
+// 设置了 HAYFRP_PASSPHRASE（或在终端中输入口令）时，密钥由口令经
+// PBKDF2-SHA256 派生；否则使用随机生成的本机密钥文件 machine.key。
+// 本机密钥只能防止 Token 以明文形式被备份或误传，不能防御能读取用户目录的攻击者。
+type fileStore struct {
+	path    string
+	keyPath string
+
+	mu         sync.Mutex
+	passphrase []byte
+}
+
+var (
+	fileStoreOnce sync.Once
+	fileStoreInst *fileStore
+)
+
+// defaultFileStore 返回 ~/.hayfrp/secrets.enc 对应的存储，同一进程内只询问一次口令
+func defaultFileStore() *fileStore {
+	fileStoreOnce.Do(func() {
+		fileStoreInst = &fileStore{
+			path:    filepath.Join(hayfrpDir(), "secrets.enc"),
+			keyPath: filepath.Join(hayfrpDir(), "machine.key"),
+		}
+	})
+	return fileStoreInst
+}
+
+// pbkdf2Iterations PBKDF2 迭代次数，参考 OWASP 对 SHA-256 的建议值
+const pbkdf2Iterations = 600000
+
+// encryptedFile secrets.enc 的文件格式
+type encryptedFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"` // "pbkdf2-sha256" 或 "machine-key"
+	Salt    []byte `json:"salt,omitempty"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+func (s *fileStore) Name() string { return "file" }
+
+func (s *fileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, _, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	return value, nil
+}
+
+func (s *fileStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, kdf, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return s.save(secrets, kdf)
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, kdf, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+	delete(secrets, key)
+	return s.save(secrets, kdf)
+}
+
+// lock 加跨进程的文件锁，守护进程、自动签到和前台命令可能同时保存会话，
+// 读取-修改-写入期间持有该锁，避免互相覆盖
+func (s *fileStore) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFileHandle(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("锁定 %s 失败: %w", s.path, err)
+	}
+	return func() {
+		unlockFileHandle(f)
+		f.Close()
+	}, nil
+}
+
+// load 读取并解密全部密钥，文件不存在时返回空表
+func (s *fileStore) load() (map[string]string, string, error) {
+	secrets := map[string]string{}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("解析 %s 失败: %w", s.path, err)
+	}
+
+	key, err := s.key(file.KDF, file.Salt, false)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("解密 %s 失败，口令或本机密钥不正确", s.path)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, "", fmt.Errorf("解析 %s 失败: %w", s.path, err)
+	}
+	return secrets, file.KDF, nil
+}
+
+// save 加密并写入全部密钥，kdf 为空时按是否设置口令决定
+func (s *fileStore) save(secrets map[string]string, kdf string) error {
+	if kdf == "" {
+		kdf = "machine-key"
+		if os.Getenv("HAYFRP_PASSPHRASE") != "" {
+			kdf = "pbkdf2-sha256"
+		}
+	}
+
+	file := encryptedFile{Version: 1, KDF: kdf}
+	if kdf == "pbkdf2-sha256" {
+		file.Salt = make([]byte, 16)
+		if _, err := rand.Read(file.Salt); err != nil {
+			return err
+		}
+	}
+
+	key, err := s.key(kdf, file.Salt, true)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免写入中断损坏已有密钥
+	return writeFileAtomic(s.path, data, 0600)
+}
+
+// key 返回加密密钥，create 为 true 时在本机密钥不存在时生成
+func (s *fileStore) key(kdf string, salt []byte, create bool) ([]byte, error) {
+	switch kdf {
+	case "pbkdf2-sha256":
+		passphrase, err := s.readPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(sha256.New, string(passphrase), salt, pbkdf2Iterations, 32)
+	case "machine-key":
+		return s.machineKey(create)
+	}
+	return nil, fmt.Errorf("不支持的加密方式: %s", kdf)
+}
+
+// readPassphrase 从 HAYFRP_PASSPHRASE 或终端读取口令
+func (s *fileStore) readPassphrase() ([]byte, error) {
+	if s.passphrase != nil {
+		return s.passphrase, nil
+	}
+	if passphrase := os.Getenv("HAYFRP_PASSPHRASE"); passphrase != "" {
+		s.passphrase = []byte(passphrase)
+		return s.passphrase, nil
+	}
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		return nil, errors.New("保存的Token已用口令加密，请设置 HAYFRP_PASSPHRASE")
+	}
+	passphrase, err := readPasswordWithMask("请输入Token加密口令")
+	if err != nil {
+		return nil, err
+	}
+	s.passphrase = []byte(passphrase)
+	return s.passphrase, nil
+}
+
+// machineKey 读取本机密钥文件，不存在且 create 为 true 时生成
+//
+// 以 O_EXCL 创建，多个进程同时生成时只有一个写入成功，其余进程读取已写入的密钥，
+// 避免各自用不同的密钥加密后无法解密。
+func (s *fileStore) machineKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("本机密钥 %s 已损坏", s.keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("读取本机密钥失败: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.keyPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return s.machineKey(false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(key)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(s.keyPath)
+		return nil, err
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestFileStoreConcurrentSet(t *testing.T) {
+	t.Setenv("HAYFRP_PASSPHRASE", "")
+	dir := t.TempDir()
+
+	// 每个 fileStore 模拟一个进程，进程内互斥锁互不影响
+	const n = 8
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store := &fileStore{path: filepath.Join(dir, "secrets.enc"), keyPath: filepath.Join(dir, "machine.key")}
+			if err := store.Set(fmt.Sprintf("session/p%d", i), fmt.Sprint(i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	store := &fileStore{path: filepath.Join(dir, "secrets.enc"), keyPath: filepath.Join(dir, "machine.key")}
+	for i := range n {
+		got, err := store.Get(fmt.Sprintf("session/p%d", i))
+		if err != nil || got != fmt.Sprint(i) {
+			t.Errorf("session/p%d = %q, %v", i, got, err)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/viper"
+)
+
+// errNotLoggedIn 既没有指定 Token 也没有保存的登录状态
+var errNotLoggedIn = errors.New("未登录，请先运行 hayfrp user login 或 hayfrp start，或通过 --token / HAYFRP_TOKEN 指定Token")
+
+// SavedSession 保存的会话信息
+//
+// Token 保存在系统钥匙串或加密文件中（见 secret.go），session.json 只记录
+// 用户名、登录时间和 Token 所在的存储后端。旧版本以明文写入的 csrf 字段
+// 会在下次读取时自动迁移。
+type SavedSession struct {
+	CSRF      string    `json:"csrf,omitempty"`
+	Username  string    `json:"username"`
+	LoginTime time.Time `json:"login_time"`
+	Store     string    `json:"store,omitempty"`
+
+	// Endpoint 最近一次成功请求使用的API端点
+	Endpoint string `json:"endpoint,omitempty"`
+	// LastVerified 最近一次确认 Token 有效的时间
+	LastVerified time.Time `json:"last_verified,omitzero"`
+
+	// AutoRelogin 为 true 时保存密码，Token 过期后自动重新登录
+	AutoRelogin bool `json:"auto_relogin,omitempty"`
+	// Password 只在保存时写入存储后端，不会写入 session.json
+	Password string `json:"-"`
+}
+
+// hayfrpDir 返回数据根目录 ~/.hayfrp，frpc 可执行文件保存在这里
+func hayfrpDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".hayfrp")
+}
+
+// sessionFilePath 返回当前账户的会话文件路径
+func sessionFilePath() string {
+	return sessionFile(currentProfile())
+}
+
+// sessionFile 返回指定账户的会话文件路径
+func sessionFile(profile string) string {
+	return filepath.Join(profileDir(profile), "session.json")
+}
+
+// sessionSecretKey 返回账户 Token 在存储后端中的键名
+func sessionSecretKey(profile string) string {
+	return "session/" + profile
+}
+
+// passwordSecretKey 返回账户密码在存储后端中的键名
+func passwordSecretKey(profile string) string {
+	return "password/" + profile
+}
+
+// resolveToken 获取子命令使用的 Token
+//
+// 优先使用 --token 参数或 HAYFRP_TOKEN 环境变量，其次使用
+// hayfrp user login / hayfrp start 为当前账户保存的登录状态。
+func resolveToken() (string, error) {
+	if token := viper.GetString("token"); token != "" {
+		return token, nil
+	}
+	session, err := loadSession(currentProfile())
+	if err != nil {
+		return "", err
+	}
+	if session == nil || session.CSRF == "" {
+		return "", errNotLoggedIn
+	}
+	return session.CSRF, nil
+}
+
+// readSessionFile 读取会话文件，不解密 Token
+func readSessionFile(path string) *SavedSession {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var session SavedSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil
+	}
+
+	return &session
+}
+
+// loadSession 加载账户保存的会话并取出 Token，没有保存的会话时返回 nil, nil
+//
+// 明文保存的旧会话会被迁移到存储后端。
+func loadSession(profile string) (*SavedSession, error) {
+	session := readSessionFile(sessionFile(profile))
+	if session == nil {
+		return nil, nil
+	}
+
+	// 旧版本明文保存的 Token
+	if session.CSRF != "" && session.Store == "" {
+		if err := saveSession(profile, session); err != nil {
+			logger.Warn("迁移明文保存的Token失败", "profile", profile, "error", err)
+		} else {
+			logger.Info("已将明文保存的Token迁移到加密存储", "profile", profile, "store", session.Store)
+		}
+		return session, nil
+	}
+
+	store := secretStoreByName(session.Store)
+	if store == nil {
+		return nil, fmt.Errorf("未知的Token存储后端: %s", session.Store)
+	}
+	csrf, err := store.Get(sessionSecretKey(profile))
+	if errors.Is(err, errSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取保存的Token失败: %w", err)
+	}
+	session.CSRF = csrf
+	return session, nil
+}
+
+// saveSession 保存会话，Token 写入存储后端，session.json 中不含 Token
+func saveSession(profile string, session *SavedSession) error {
+	if err := os.MkdirAll(profileDir(profile), 0755); err != nil {
+		return err
+	}
+
+	store := defaultSecretStore()
+	err := store.Set(sessionSecretKey(profile), session.CSRF)
+	if err != nil && store.Name() != "file" {
+		// 钥匙串被锁定等情况下退回加密文件
+		logger.Warn("写入系统钥匙串失败，改用加密文件", "store", store.Name(), "error", err)
+		store = defaultFileStore()
+		err = store.Set(sessionSecretKey(profile), session.CSRF)
+	}
+	if err != nil {
+		return fmt.Errorf("保存Token失败: %w", err)
+	}
+
+	// 自动重新登录需要的密码与 Token 保存在同一后端
+	if !session.AutoRelogin {
+		store.Delete(passwordSecretKey(profile))
+	} else if session.Password != "" {
+		if err := store.Set(passwordSecretKey(profile), session.Password); err != nil {
+			return fmt.Errorf("保存密码失败: %w", err)
+		}
+	}
+
+	// 切换后端时清理旧后端中的 Token
+	if old := secretStoreByName(session.Store); old != nil && old.Name() != store.Name() {
+		old.Delete(sessionSecretKey(profile))
+		old.Delete(passwordSecretKey(profile))
+	}
+
+	session.Store = store.Name()
+	return writeSessionFile(profile, session)
+}
+
+// writeSessionFile 写入会话文件，不含 Token
+func writeSessionFile(profile string, session *SavedSession) error {
+	meta := *session
+	meta.CSRF = ""
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sessionFile(profile), data, 0600)
+}
+
+// touchSession 记录 Token 验证成功的时间和使用的端点
+func touchSession(profile string) {
+	session := readSessionFile(sessionFile(profile))
+	if session == nil {
+		return
+	}
+	session.LastVerified = time.Now()
+	session.Endpoint = api.GetCurrentEndpoint()
+	if err := writeSessionFile(profile, session); err != nil {
+		logger.Warn("更新会话信息失败", "profile", profile, "error", err)
+	}
+}
+
+// sessionMaxAge 返回配置的会话最长有效期 session.max_age，0 表示不限制
+func sessionMaxAge() time.Duration {
+	return viper.GetDuration("session.max_age")
+}
+
+// stale 判断会话是否已超过 session.max_age
+func (s *SavedSession) stale() bool {
+	maxAge := sessionMaxAge()
+	return maxAge > 0 && time.Since(s.LoginTime) > maxAge
+}
+
+// removeSession 删除账户保存的会话及其 Token 和密码，会话不存在时返回 os.ErrNotExist
+//
+// 删除密钥失败时保留会话文件，以便重试时仍能找到保存密钥的位置。
+func removeSession(profile string) error {
+	var errs []error
+	session := readSessionFile(sessionFile(profile))
+	if session != nil {
+		if store := secretStoreByName(session.Store); store != nil {
+			for _, key := range []string{sessionSecretKey(profile), passwordSecretKey(profile)} {
+				if err := store.Delete(key); err != nil {
+					logger.Warn("删除保存的密钥失败", "profile", profile, "store", session.Store, "key", key, "error", err)
+					errs = append(errs, fmt.Errorf("从 %s 删除 %s 失败: %w", session.Store, key, err))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return os.Remove(sessionFile(profile))
+}
+
+// errNoAutoRelogin 登录过期且账户未开启自动重新登录
+var errNoAutoRelogin = errors.New("登录已过期，且未开启自动重新登录 (hayfrp user login --remember)")
+
+// reloginImpossible 判断自动重新登录失败是否因为无法重新登录（未保存密码或密码已失效），
+// 而非网络、服务器异常等临时故障
+func reloginImpossible(err error) bool {
+	return errors.Is(err, errNoAutoRelogin) || errors.Is(err, api.ErrForbidden)
+}
+
+// sessionExpired 判断请求失败是否意味着保存的会话已失效：服务端确认 Token 过期且无法自动重新登录
+//
+// 刷新 Token 时遇到临时故障不算失效，保留会话与保存的密码以便稍后重试。
+func sessionExpired(err error) bool {
+	if !errors.Is(err, api.ErrTokenExpired) {
+		return false
+	}
+	return !errors.Is(err, api.ErrRefreshFailed) || reloginImpossible(err)
+}
+
+// refreshSession 在 Token 过期后为账户获取新的 Token 并更新保存的会话
+//
+// 保存的会话已被其他命令刷新时直接使用新的 Token；否则先验证 Token 是否确实失效，
+// 以便区分网络故障。Token 仍通过验证但请求已被拒绝时，原样重放同样会失败，
+// 因此两种情况下都在用户登录时选择了记住密码的前提下重新登录。
+func refreshSession(ctx context.Context, profile, expired string) (string, error) {
+	if session, err := loadSession(profile); err == nil && session != nil && session.CSRF != "" && session.CSRF != expired {
+		logger.Info("使用已刷新的Token", "profile", profile)
+		return session.CSRF, nil
+	}
+
+	// 不设置 WithTokenRefresher，避免刷新过程中再次触发刷新
+	client := api.NewUserAPIClient(apiOptions()...)
+
+	_, err := client.VerifyCsrfContext(ctx, expired)
+	switch {
+	case err == nil:
+		logger.Info("Token仍通过验证但请求被拒绝，重新登录", "profile", profile)
+	case !errors.Is(err, api.ErrTokenExpired):
+		return "", err
+	}
+
+	return reloginSession(ctx, profile)
+}
+
+// reloginSession 使用保存的密码重新登录并更新保存的会话
+func reloginSession(ctx context.Context, profile string) (string, error) {
+	client := api.NewUserAPIClient(apiOptions()...)
+
+	session := readSessionFile(sessionFile(profile))
+	if session == nil || !session.AutoRelogin {
+		return "", errNoAutoRelogin
+	}
+	store := secretStoreByName(session.Store)
+	if store == nil {
+		return "", errNoAutoRelogin
+	}
+	password, err := store.Get(passwordSecretKey(profile))
+	if err != nil {
+		return "", fmt.Errorf("读取保存的密码失败: %w", err)
+	}
+
+	resp, err := client.LoginContext(ctx, session.Username, password)
+	if err != nil {
+		return "", fmt.Errorf("自动重新登录失败: %w", err)
+	}
+
+	session.CSRF = resp.Token
+	session.LoginTime = time.Now()
+	session.LastVerified = session.LoginTime
+	session.Endpoint = api.GetCurrentEndpoint()
+	session.Password = password
+	if err := saveSession(profile, session); err != nil {
+		logger.Warn("保存刷新后的Token失败", "profile", profile, "error", err)
+	}
+	logger.Info("已使用保存的密码重新登录", "profile", profile, "username", session.Username)
+	return resp.Token, nil
+}
+
+// sessionRefresher 返回刷新账户 Token 的 api.TokenRefresher，onRefresh 不为 nil 时在刷新成功后调用
+func sessionRefresher(profile string, onRefresh func(csrf string)) api.TokenRefresher {
+	return func(ctx context.Context, expired string) (string, error) {
+		csrf, err := refreshSession(ctx, profile, expired)
+		if err == nil && onRefresh != nil {
+			onRefresh(csrf)
+		}
+		return csrf, err
+	}
+}
@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// 自动签到的默认时间和随机延迟
+const (
+	defaultSignTime   = "08:00"
+	defaultSignJitter = 30 * time.Minute
+	// signRetryDelay 签到失败后的重试间隔
+	signRetryDelay = 15 * time.Minute
+)
+
+// signRecord 一次签到的记录，按行保存在账户目录的 sign_history.jsonl 中
+type signRecord struct {
+	Time     time.Time `json:"time"`
+	Username string    `json:"username,omitempty"`
+	Signflow float64   `json:"signflow"` // 签到获得的流量，单位 GB
+	Flow     float64   `json:"flow"`     // 签到后剩余流量，单位 GB
+	Message  string    `json:"message,omitempty"`
+}
+
+// signState 账户的签到状态，保存在账户目录的 sign.json 中
+type signState struct {
+	// LastSigndate 最近一次签到的时间，当天已签到时不再请求签到接口
+	LastSigndate time.Time `json:"last_signdate,omitzero"`
+}
+
+func signHistoryFile(profile string) string {
+	return filepath.Join(profileDir(profile), "sign_history.jsonl")
+}
+
+func signStateFile(profile string) string {
+	return filepath.Join(profileDir(profile), "sign.json")
+}
+
+// readSignState 读取账户的签到状态，文件不存在或损坏时返回零值
+func readSignState(profile string) signState {
+	var state signState
+	data, err := os.ReadFile(signStateFile(profile))
+	if err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func writeSignState(profile string, state signState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(profileDir(profile), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(signStateFile(profile), data, 0600)
+}
+
+// recordSign 记录签到结果：更新签到状态并追加签到历史
+func recordSign(profile, username string, resp *api.SignResponse) error {
+	now := time.Now()
+	if err := writeSignState(profile, signState{LastSigndate: now}); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(signRecord{
+		Time:     now,
+		Username: username,
+		Signflow: resp.Signflow,
+		Flow:     resp.Flow,
+		Message:  resp.Message,
+	})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(signHistoryFile(profile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// readSignHistory 读取账户的签到历史，按时间先后排列，跳过无法解析的行
+func readSignHistory(profile string) ([]signRecord, error) {
+	f, err := os.Open(signHistoryFile(profile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []signRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record signRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// sameDay 判断两个时间是否为本地时间的同一天
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// signProfile 为账户签到，今天已签到时跳过并返回 nil, nil
+//
+// 先检查本地记录的签到时间，再通过用户信息中的签到日期确认，
+// 避免在网页或其他设备签到后重复请求签到接口。
+func signProfile(ctx context.Context, profile string) (*api.SignResponse, error) {
+	state := readSignState(profile)
+	if sameDay(state.LastSigndate, time.Now()) {
+		return nil, nil
+	}
+
+	session, err := loadSession(profile)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.CSRF == "" {
+		return nil, errNotLoggedIn
+	}
+
+	csrf := session.CSRF
+	client := newProfileUserClient(profile, func(fresh string) { csrf = fresh })
+
+	info, err := client.GetInfoContext(ctx, csrf)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	if sameDay(info.Signdate, time.Now()) {
+		if err := writeSignState(profile, signState{LastSigndate: info.Signdate}); err != nil {
+			logger.Warn("保存签到状态失败", "profile", profile, "error", err)
+		}
+		return nil, nil
+	}
+
+	resp, err := client.SignContext(ctx, csrf)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordSign(profile, session.Username, resp); err != nil {
+		logger.Warn("保存签到记录失败", "profile", profile, "error", err)
+	}
+	return resp, nil
+}
+
+// autoSignProfiles 返回自动签到的账户
+//
+// 通过 --profile 指定账户时只签到该账户，其次使用配置 sign.profiles，
+// 都未设置时签到所有已登录的账户。
+func autoSignProfiles() ([]string, error) {
+	if profile := viper.GetString("profile"); profile != "" {
+		return []string{profile}, nil
+	}
+	if profiles := viper.GetStringSlice("sign.profiles"); len(profiles) > 0 {
+		for _, name := range profiles {
+			if err := validateProfileName(name); err != nil {
+				return nil, err
+			}
+		}
+		return profiles, nil
+	}
+
+	all, err := listProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var profiles []string
+	for _, name := range all {
+		if readSessionFile(sessionFile(name)) != nil {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles, nil
+}
+
+// signScheduled 为已到签到时间的账户签到并安排下次签到时间，返回最早的下次签到时间
+//
+// 每个账户单独抽取随机延迟，多个账户不会在同一时刻签到。账户首次加入时
+// 已过当天的签到时间则立即补签；签到失败的账户在 signRetryDelay 后重试。
+func signScheduled(ctx context.Context, schedule map[string]time.Time, clock, jitter time.Duration) (time.Time, error) {
+	profiles, err := autoSignProfiles()
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+	if len(profiles) == 0 {
+		signLog("没有已登录的账户")
+		clear(schedule)
+		return nextSignTime(now, clock, jitter), nil
+	}
+
+	var earliest time.Time
+	for _, profile := range profiles {
+		at, ok := schedule[profile]
+		if !ok {
+			at = nextSignTime(now, clock, jitter)
+			if !now.Before(clockOn(now, clock)) {
+				at = now
+			}
+		}
+		if !at.After(now) {
+			failed := signOne(ctx, profile)
+			if ctx.Err() != nil {
+				return time.Time{}, ctx.Err()
+			}
+			if failed {
+				at = time.Now().Add(signRetryDelay)
+			} else {
+				at = nextSignTime(time.Now(), clock, jitter)
+			}
+		}
+		if at != schedule[profile] {
+			signLog("[%s] 下次签到时间: %s", profile, at.Format(time.DateTime))
+		}
+		schedule[profile] = at
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+
+	// 移除已退出登录或不再签到的账户
+	for profile := range schedule {
+		if !slices.Contains(profiles, profile) {
+			delete(schedule, profile)
+		}
+	}
+	return earliest, nil
+}
+
+// signOne 为账户签到并输出结果，返回是否签到失败
+func signOne(ctx context.Context, profile string) bool {
+	resp, err := signProfile(ctx, profile)
+	switch {
+	case ctx.Err() != nil:
+		return false
+	case err != nil:
+		signLog("✗ [%s] 签到失败: %v", profile, err)
+		return true
+	case resp == nil:
+		signLog("[%s] 今天已签到，跳过", profile)
+	default:
+		signLog("✓ [%s] %s (获得 %.2f GB，剩余 %.2f GB)", profile, resp.Message, resp.Signflow, resp.Flow)
+	}
+	return false
+}
+
+// nextSignTime 返回 now 之后下一个签到时间加上随机延迟
+func nextSignTime(now time.Time, clock, jitter time.Duration) time.Time {
+	day := now
+	if !now.Before(clockOn(now, clock)) {
+		day = now.AddDate(0, 0, 1)
+	}
+	next := clockOn(day, clock)
+	if jitter > 0 {
+		next = next.Add(rand.N(jitter))
+	}
+	return next
+}
+
+// signLog 输出带时间的自动签到日志
+func signLog(format string, args ...any) {
+	fmt.Printf("[%s] %s\n", time.Now().Format(time.DateTime), fmt.Sprintf(format, args...))
+}
+
+// parseSignTime 解析 HH:MM 格式的签到时间，返回距当天零点的时长
+func parseSignTime(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的签到时间 %q，格式应为 HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// clockOn 返回 day 当天的指定时刻
+func clockOn(day time.Time, clock time.Duration) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, day.Location()).Add(clock)
+}
+
+// runAutoSign 每天在指定时间加上随机延迟后为账户签到，直到 ctx 被取消
+//
+// 每个账户的签到时间见 signScheduled；读取账户列表失败时每隔 signRetryDelay 重试。
+func runAutoSign(ctx context.Context, clock, jitter time.Duration) {
+	schedule := make(map[string]time.Time)
+	for {
+		next, err := signScheduled(ctx, schedule, clock, jitter)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			signLog("✗ 读取账户列表失败: %v", err)
+			next = time.Now().Add(signRetryDelay)
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+var signHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "查看签到记录",
+	Long:  `查看当前账户的签到记录，包括 hayfrp user sign 和自动签到的结果`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := currentProfile()
+		records, err := readSignHistory(profile)
+		if err != nil {
+			fmt.Printf("✗ 读取签到记录失败: %v\n", err)
+			return
+		}
+		if len(records) == 0 {
+			fmt.Println("暂无签到记录")
+			return
+		}
+
+		var total float64
+		for _, record := range records {
+			total += record.Signflow
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		shown := records
+		if limit > 0 && len(shown) > limit {
+			shown = shown[len(shown)-limit:]
+		}
+
+		fmt.Printf("========== 签到记录 (%s) ==========\n", profile)
+		for _, record := range shown {
+			fmt.Printf("%s  %s  获得 %.2f GB  剩余 %.2f GB\n",
+				record.Time.Local().Format(time.DateTime), record.Username, record.Signflow, record.Flow)
+		}
+		fmt.Printf("================================\n")
+		fmt.Printf("共签到 %d 次，累计获得 %.2f GB\n", len(records), total)
+	},
+}
+
+// startAutoSign 运行 hayfrp user sign --auto
+func startAutoSign(cmd *cobra.Command) {
+	if viper.GetString("token") != "" {
+		fmt.Println("✗ 自动签到使用保存的登录状态，不支持 --token / HAYFRP_TOKEN")
+		return
+	}
+
+	at := viper.GetString("sign.time")
+	clock, err := parseSignTime(at)
+	if err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+	jitter := viper.GetDuration("sign.jitter")
+	if jitter < 0 || jitter >= 24*time.Hour {
+		fmt.Println("✗ 随机延迟应在 0 到 24h 之间")
+		return
+	}
+
+	ctx, stop := interruptContext(cmd.Context())
+	defer stop()
+
+	signLog("自动签到已启动，每天 %s 后 %s 内签到，按 Ctrl+C 退出", at, jitter)
+	runAutoSign(ctx, clock, jitter)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Println("\n已停止自动签到")
+	}
+}
+
+func init() {
+	signCmd.AddCommand(signHistoryCmd)
+
+	signCmd.Flags().Bool("auto", false, "持续运行，每天定时为已登录的账户自动签到")
+	signCmd.Flags().String("at", defaultSignTime, "自动签到时间 HH:MM")
+	signCmd.Flags().Duration("jitter", defaultSignJitter, "自动签到的随机延迟上限")
+	viper.BindPFlag("sign.time", signCmd.Flags().Lookup("at"))
+	viper.BindPFlag("sign.jitter", signCmd.Flags().Lookup("jitter"))
+
+	signHistoryCmd.Flags().IntP("limit", "n", 30, "最多显示的记录数，0 表示全部")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextSignTime(t *testing.T) {
+	clock := 8 * time.Hour
+	jitter := 30 * time.Minute
+	before := time.Date(2024, 5, 1, 7, 0, 0, 0, time.Local)
+	after := time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{before, time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)},
+		{after, time.Date(2024, 5, 2, 8, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got := nextSignTime(tt.now, clock, jitter)
+			if got.Before(tt.want) || !got.Before(tt.want.Add(jitter)) {
+				t.Fatalf("nextSignTime(%s) = %s，期望在 %s 之后 %s 内", tt.now, got, tt.want, jitter)
+			}
+		}
+		if got := nextSignTime(tt.now, clock, 0); !got.Equal(tt.want) {
+			t.Errorf("nextSignTime(%s, 无延迟) = %s，期望 %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestNextSignTimePerDraw(t *testing.T) {
+	// 每次调用单独抽取延迟，多个账户的签到时间不应全部相同
+	now := time.Date(2024, 5, 1, 7, 0, 0, 0, time.Local)
+	first := nextSignTime(now, 8*time.Hour, time.Hour)
+	for range 20 {
+		if !nextSignTime(now, 8*time.Hour, time.Hour).Equal(first) {
+			return
+		}
+	}
+	t.Error("多次抽取的签到时间全部相同")
+}
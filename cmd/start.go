@@ -5,12 +5,12 @@ import (
 	"archive/zip"
 	"bufio"
 	"compress/gzip"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -20,51 +20,104 @@ import (
 	"hayfrp-cli/api"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// SavedSession 保存的会话信息
-type SavedSession struct {
-	CSRF      string    `json:"csrf"`
-	Username  string    `json:"username"`
-	LoginTime time.Time `json:"login_time"`
-}
-
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "启动隧道（交互式）",
 	Long:  `交互式启动流程：登录 -> 选择隧道 -> 启动隧道`,
 	Run: func(cmd *cobra.Command, args []string) {
 		reader := bufio.NewReader(os.Stdin)
-		homeDir, _ := os.UserHomeDir()
-		configDir := filepath.Join(homeDir, ".hayfrp")
-		sessionFile := filepath.Join(configDir, "session.json")
+		baseCtx := cmd.Context()
+		configDir := hayfrpDir()
+
+		// 未通过 --profile 指定账户且存在多个账户时，先选择账户
+		if viper.GetString("profile") == "" {
+			pickProfile(reader)
+		}
+		profile := currentProfile()
 
 		// 主循环：支持退出账户后重新登录
 		for {
 
 		// 步骤1: 尝试自动登录
 		fmt.Println("========== HayFrp 隧道启动器 ==========")
+		if profile != defaultProfile {
+			fmt.Printf("当前账户: %s\n", profile)
+		}
 
 		csrf := ""
-		userClient := api.NewUserAPIClient()
+		// Token 过期时按保存的密码自动重新登录，并更新当前使用的 Token
+		refresher := api.WithTokenRefresher(sessionRefresher(profile, func(fresh string) {
+			csrf = fresh
+		}))
+		userClient := newUserClient(refresher)
 
 		// 主循环：支持退出账户后重新登录
 		for {
 			// 如果未登录，尝试登录
 			if csrf == "" {
 				// 尝试读取保存的会话
-				if session := loadSession(sessionFile); session != nil {
+				session, err := loadSession(profile)
+				if err != nil {
+					fmt.Printf("✗ %v\n", err)
+				}
+				if session != nil && session.stale() {
+					fmt.Printf("检测到保存的登录信息 (用户: %s)\n", session.Username)
+					ctx, stop := interruptContext(baseCtx)
+					fresh, err := renewSession(ctx, profile, session)
+					stop()
+					if isCanceled(err) {
+						return
+					}
+					if err == nil {
+						csrf = fresh
+						fmt.Printf("✓ 自动登录成功！\n\n")
+					} else {
+						fmt.Printf("✗ %v\n", err)
+						fmt.Println("请重新登录")
+					}
+				} else if session != nil {
 					fmt.Printf("检测到保存的登录信息 (用户: %s)\n", session.Username)
 					fmt.Print("正在验证 Token 有效性... ")
 
 					// 验证 token 是否有效
-					verifyResp, err := userClient.VerifyCsrf(session.CSRF)
-					if err == nil && verifyResp.Status == 200 {
+					ctx, stop := interruptContext(baseCtx)
+					_, err := userClient.VerifyCsrfContext(ctx, session.CSRF)
+					stop()
+					if isCanceled(err) {
+						return
+					}
+					if err == nil {
 						fmt.Println("有效!")
 						csrf = session.CSRF
+						touchSession(profile)
 						fmt.Printf("✓ 自动登录成功！\n\n")
-					} else {
+					} else if errors.Is(err, api.ErrTokenExpired) && session.AutoRelogin {
 						fmt.Println("已过期")
+						fmt.Print("正在使用保存的密码重新登录... ")
+						ctx, stop := interruptContext(baseCtx)
+						fresh, err := reloginSession(ctx, profile)
+						stop()
+						if isCanceled(err) {
+							return
+						}
+						if err == nil {
+							fmt.Println("成功!")
+							csrf = fresh
+							fmt.Printf("✓ 自动登录成功！\n\n")
+						} else {
+							fmt.Println("失败")
+							fmt.Printf("✗ %v\n", err)
+							fmt.Println("请重新登录")
+						}
+					} else if errors.Is(err, api.ErrTokenExpired) {
+						fmt.Println("已过期")
+						fmt.Println("请重新登录")
+					} else {
+						fmt.Println("失败")
+						fmt.Printf("✗ %v\n", err)
 						fmt.Println("请重新登录")
 					}
 				}
@@ -81,27 +134,34 @@ var startCmd = &cobra.Command{
 						continue
 					}
 
-					loginResp, err := userClient.Login(username, password)
+					ctx, stop := interruptContext(baseCtx)
+					loginResp, err := userClient.LoginContext(ctx, username, password)
+					stop()
+					if isCanceled(err) {
+						return
+					}
 					if err != nil {
 						fmt.Printf("✗ 登录失败: %v\n", err)
 						continue
 					}
 
-					if loginResp.Status != 200 {
-						fmt.Printf("✗ 登录失败: %s\n", loginResp.Message)
-						continue
-					}
-
 					csrf = loginResp.Token
 
 					// 保存会话
-					os.MkdirAll(configDir, 0755)
 					session := &SavedSession{
-						CSRF:      csrf,
-						Username:  username,
-						LoginTime: time.Now(),
+						CSRF:         csrf,
+						Username:     username,
+						LoginTime:    time.Now(),
+						LastVerified: time.Now(),
+						Endpoint:     api.GetCurrentEndpoint(),
+					}
+					fmt.Print("记住密码，登录过期后自动重新登录? (y/N): ")
+					remember, _ := reader.ReadString('\n')
+					if strings.ToLower(strings.TrimSpace(remember)) == "y" {
+						session.AutoRelogin = true
+						session.Password = password
 					}
-					if err := saveSession(sessionFile, session); err == nil {
+					if err := saveSession(profile, session); err == nil {
 						fmt.Printf("✓ 登录成功！(已保存登录状态)\n\n")
 					} else {
 						fmt.Printf("✓ 登录成功！\n\n")
@@ -110,45 +170,72 @@ var startCmd = &cobra.Command{
 			}
 
 			// 步骤2: 获取用户信息
-			infoResp, err := userClient.GetInfo(csrf)
+			ctx, stop := interruptContext(baseCtx)
+			infoResp, err := userClient.GetInfoContext(ctx, csrf)
+			stop()
+			if isCanceled(err) {
+				return
+			}
 			if err != nil {
 				fmt.Printf("✗ 获取用户信息失败: %v\n", err)
+				// 网络等临时故障保留登录状态，重试即可
+				if !sessionExpired(err) && !errors.Is(err, api.ErrAccountDisabled) {
+					fmt.Print("\n按任意键重试...")
+					reader.ReadString('\n')
+					continue
+				}
+				// 登录过期时清除保存的会话，避免再次自动登录
+				removeSession(profile)
 				// 返回登录流程
 				csrf = ""
 				continue
 			}
-			if !infoResp.Status {
-				fmt.Printf("✗ %s\n", infoResp.Message)
-				// 返回登录流程
-				csrf = ""
-				continue
+			if err := recordTraffic(profile, infoResp); err != nil {
+				logger.Warn("保存流量记录失败", "profile", profile, "error", err)
 			}
 			fmt.Printf("========== 用户信息 ==========\n")
 			fmt.Printf("用户: %s\n", infoResp.Username)
-			// 转换流量为GB
-			var trafficGB float64
-			switch v := infoResp.Traffic.(type) {
-			case string:
-				if val, err := strconv.ParseFloat(v, 64); err == nil {
-					trafficGB = val / 1024
-				}
-			case float64:
-				trafficGB = v / 1024
-			}
-			fmt.Printf("剩余流量: %.2f GB\n", trafficGB)
-			fmt.Printf("拥有隧道: %v / 已使用: %v\n", infoResp.Proxies, infoResp.Useproxies)
+			fmt.Printf("剩余流量: %s\n", infoResp.Traffic)
+			fmt.Printf("拥有隧道: %d / 已使用: %d\n", infoResp.Proxies, infoResp.Useproxies)
 			fmt.Printf("================================\n")
 			fmt.Printf("[0] 退出账户\n\n")
 
 			// 隧道选择循环
-			proxyClient := api.NewProxyAPIClient()
+			proxyClient := newProxyClient(refresher)
 			for {
 				// 检查是否已登录
 				if csrf == "" {
 					break
 				}
+				// 登录超过 session.max_age 时主动重新登录
+				if session := readSessionFile(sessionFile(profile)); session != nil && session.stale() {
+					ctx, stop := interruptContext(baseCtx)
+					fresh, err := renewSession(ctx, profile, session)
+					stop()
+					if isCanceled(err) {
+						return
+					}
+					if err != nil {
+						fmt.Printf("✗ %v\n", err)
+						csrf = ""
+						break
+					}
+					csrf = fresh
+				}
 				// 步骤3: 获取隧道列表
-				listResp, err := proxyClient.ListTunnel(csrf, "")
+				ctx, stop := interruptContext(baseCtx)
+				listResp, err := proxyClient.ListTunnelContext(ctx, csrf, "")
+				stop()
+				if isCanceled(err) {
+					return
+				}
+				if sessionExpired(err) {
+					// 登录过期，直接返回登录流程重新登录
+					fmt.Println("✗ 登录已过期，请重新登录")
+					removeSession(profile)
+					csrf = ""
+					break
+				}
 				if err != nil {
 					fmt.Printf("✗ 获取隧道列表失败: %v\n", err)
 					fmt.Print("\n按任意键重试...")
@@ -156,7 +243,7 @@ var startCmd = &cobra.Command{
 					continue
 				}
 
-				if listResp.Status != 200 || len(listResp.Proxies) == 0 {
+				if len(listResp.Proxies) == 0 {
 					fmt.Println("✗ 暂无可用隧道，请先在控制台创建隧道")
 					fmt.Print("\n按任意键重试...")
 					reader.ReadString('\n')
@@ -179,7 +266,7 @@ var startCmd = &cobra.Command{
 				fmt.Println("================================")
 
 				// 步骤4: 选择隧道
-				fmt.Print("\n请选择要启动的隧道编号 [0退出]: ")
+				fmt.Print("\n请选择要启动的隧道编号，多个用逗号分隔，all 为全部 [0退出]: ")
 				choice, _ := reader.ReadString('\n')
 				choice = strings.TrimSpace(choice)
 
@@ -191,7 +278,7 @@ var startCmd = &cobra.Command{
 					confirm = strings.TrimSpace(confirm)
 					if strings.ToLower(confirm) == "y" {
 						// 删除会话文件
-						os.Remove(sessionFile)
+						removeSession(profile)
 						fmt.Println("✓ 已退出账户")
 						// 返回到登录流程
 						csrf = ""
@@ -202,109 +289,77 @@ var startCmd = &cobra.Command{
 					}
 				}
 
-				var choiceIndex int
-				_, err = fmt.Sscanf(choice, "%d", &choiceIndex)
-				if err != nil || choiceIndex < 1 || choiceIndex > len(listResp.Proxies) {
-					fmt.Println("✗ 无效的选择")
+				indexes, err := parseSelection(choice, len(listResp.Proxies))
+				if err != nil {
+					fmt.Printf("✗ %v\n", err)
 					fmt.Print("\n按任意键重试...")
 					reader.ReadString('\n')
 					continue
 				}
-
-				selectedProxy := listResp.Proxies[choiceIndex-1]
+				selected := make([]api.TunnelInfo, len(indexes))
+				for i, index := range indexes {
+					selected[i] = listResp.Proxies[index]
+				}
 
 				// 检查隧道状态
-				if selectedProxy.Status != "true" {
-					fmt.Printf("隧道 %s 当前状态为禁用，正在启用...\n", selectedProxy.ProxyName)
-					toggleResp, err := proxyClient.ToggleTunnel(csrf, selectedProxy.ID, "true")
-					if err != nil {
-						fmt.Printf("✗ 启用隧道失败: %v\n", err)
-						fmt.Print("\n按任意键重试...")
-						reader.ReadString('\n')
-						continue
-					}
-					if toggleResp.Status != 200 {
-						fmt.Printf("✗ 启用隧道失败: %s\n", toggleResp.Message)
-						fmt.Print("\n按任意键重试...")
-						reader.ReadString('\n')
-						continue
-					}
-					fmt.Printf("✓ 隧道已启用\n")
+				ctx, stop = interruptContext(baseCtx)
+				err = enableTunnels(ctx, proxyClient, csrf, selected)
+				stop()
+				if isCanceled(err) {
+					return
 				}
-
-				// 步骤5: 生成配置文件
-				fmt.Printf("\n正在为隧道 %s 生成配置文件...\n", selectedProxy.ProxyName)
-				config, err := proxyClient.GetTunnelConfig("toml", csrf, "", selectedProxy.ID)
 				if err != nil {
-					fmt.Printf("✗ 生成配置文件失败: %v\n", err)
+					fmt.Printf("✗ %v\n", err)
 					fmt.Print("\n按任意键重试...")
 					reader.ReadString('\n')
 					continue
 				}
 
-				// 保存配置文件到用户目录
-				if homeDir == "" {
-					homeDir = "."
+				// 步骤5: 生成配置文件，同一节点的隧道使用同一个配置文件
+				fmt.Printf("\n正在为 %d 个隧道生成配置文件...\n", len(selected))
+				ctx, stop = interruptContext(baseCtx)
+				groups, err := prepareTunnels(ctx, proxyClient, csrf, profile, selected, listResp.Proxies)
+				stop()
+				if isCanceled(err) {
+					return
 				}
-
-				configDir = filepath.Join(homeDir, ".hayfrp")
-				if err := os.MkdirAll(configDir, 0755); err != nil {
-					configDir = "."
-				}
-
-				configFile := filepath.Join(configDir, "frpc.toml")
-				if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
-					fmt.Printf("✗ 保存配置文件失败: %v\n", err)
+				if err != nil {
+					fmt.Printf("✗ 生成配置文件失败: %v\n", err)
 					fmt.Print("\n按任意键重试...")
 					reader.ReadString('\n')
 					continue
 				}
-
-				fmt.Printf("✓ 配置文件已保存: %s\n", configFile)
+				for _, group := range groups {
+					fmt.Printf("✓ 配置文件已保存: %s\n", group.File)
+				}
 
 				// 步骤6: 启动frpc
 				fmt.Printf("\n========== 启动frpc ==========\n")
 
 				// 检查frpc可执行文件
-				frpcPath := ""
-				frpcName := "frpc"
-				if runtime.GOOS == "windows" {
-					frpcName = "frpc.exe"
-				}
-
-				possiblePaths := []string{
-					filepath.Join(".", frpcName),
-					filepath.Join(homeDir, ".hayfrp", frpcName),
-				}
-
-				// Unix 系统额外路径
-				if runtime.GOOS != "windows" {
-					possiblePaths = append(possiblePaths,
-						"/usr/local/bin/frpc",
-						"/usr/bin/frpc",
-					)
-				}
-
-				for _, path := range possiblePaths {
-					if _, err := os.Stat(path); err == nil {
-						frpcPath = path
-						break
-					}
-				}
-
+				frpcPath := locateFrpc()
 				if frpcPath == "" {
 					fmt.Println("未找到 frpc 可执行文件，正在尝试自动下载...")
 
 					// 自动下载 frpc
-					downloadResp, err := downloadFrpc(homeDir, configDir)
+					if err := os.MkdirAll(configDir, 0755); err != nil {
+						configDir = "."
+					}
+					ctx, stop := interruptContext(baseCtx)
+					downloadResp, err := downloadFrpc(ctx, configDir)
+					stop()
+					if isCanceled(err) {
+						return
+					}
 					if err != nil {
 						fmt.Printf("✗ 自动下载 frpc 失败: %v\n", err)
 						fmt.Println("\n请手动下载 frpc:")
-
 						// 获取下载列表
-						nodeClient := api.NewNodeAPIClient()
-						downloadList, err := nodeClient.GetDownloadList()
-						if err == nil && downloadList.Status == 200 {
+						nodeClient := newNodeClient()
+						ctx, stop := interruptContext(baseCtx)
+						downloadList, err := nodeClient.GetDownloadListContext(ctx)
+						stop()
+						if err == nil {
 							fmt.Println("\n下载源:")
 							for _, source := range downloadList.Sources {
 								fmt.Printf("  - %s: %s\n", source.Name, source.URL)
@@ -327,7 +382,7 @@ var startCmd = &cobra.Command{
 						}
 
 						fmt.Printf("\n下载后请将 frpc 放到以下任一路径:\n")
-						for _, path := range possiblePaths {
+						for _, path := range frpcCandidates() {
 							fmt.Printf("  - %s\n", path)
 						}
 						fmt.Print("\n按任意键重试...")
@@ -340,19 +395,25 @@ var startCmd = &cobra.Command{
 				}
 
 				fmt.Printf("使用 frpc: %s\n", frpcPath)
-				fmt.Printf("配置文件: %s\n", configFile)
+				if len(groups) > 1 {
+					fmt.Printf("同时运行 %d 个 frpc\n", len(groups))
+				}
 				fmt.Println("\n按 Ctrl+C 可停止隧道")
-				fmt.Println("================================\n")
+				fmt.Print("================================\n\n")
 
-				// 启动frpc
-				frpcExec := exec.Command(frpcPath, "-c", configFile)
-				frpcExec.Stdout = os.Stdout
-				frpcExec.Stderr = os.Stderr
+				// 启动frpc，意外退出时自动重启，Ctrl+C 停止 frpc 后返回隧道列表
+				ctx, stop = interruptContext(baseCtx)
 
-				if err := frpcExec.Run(); err != nil {
-					fmt.Printf("\n✗ frpc 启动失败: %v\n", err)
+				// 隧道运行期间记录流量并在剩余流量不足时告警
+				go watchTraffic(ctx, profile)
+				err = runTunnelGroups(ctx, frpcPath, groups)
+				stop()
+				if err != nil {
+					fmt.Printf("\n✗ frpc 运行失败: %v\n", err)
 					fmt.Print("\n按任意键返回隧道列表...")
 					reader.ReadString('\n')
+				} else {
+					fmt.Println("\n✓ 隧道已停止")
 				}
 			}
 		}
@@ -360,15 +421,112 @@ var startCmd = &cobra.Command{
 	},
 }
 
+// renewSession 在登录超过 session.max_age 后主动更新会话
+//
+// 开启了自动重新登录时使用保存的密码重新登录，否则清除保存的会话，要求用户重新登录。
+// 网络等临时故障导致重新登录失败时保留会话与保存的密码，下次启动时再次尝试。
+func renewSession(ctx context.Context, profile string, session *SavedSession) (string, error) {
+	fmt.Printf("登录已超过最长有效期 %s\n", sessionMaxAge())
+	if !session.AutoRelogin {
+		removeSession(profile)
+		return "", errNoAutoRelogin
+	}
+
+	fmt.Print("正在使用保存的密码重新登录... ")
+	fresh, err := reloginSession(ctx, profile)
+	if err != nil {
+		fmt.Println("失败")
+		if reloginImpossible(err) {
+			removeSession(profile)
+		}
+		return "", err
+	}
+	fmt.Println("成功!")
+	return fresh, nil
+}
+
+// pickProfile 存在多个账户时让用户选择本次使用的账户，直接回车使用当前账户
+func pickProfile(reader *bufio.Reader) {
+	profiles, err := listProfiles()
+	if err != nil || len(profiles) <= 1 {
+		return
+	}
+
+	current := currentProfile()
+	fmt.Println("========== 选择账户 ==========")
+	for i, name := range profiles {
+		fmt.Printf("%d. %s %s\n", i+1, name, describeProfile(name))
+	}
+	fmt.Println("================================")
+
+	for {
+		fmt.Printf("请选择账户编号 [回车使用 %s]: ", current)
+		choice, _ := reader.ReadString('\n')
+		choice = strings.TrimSpace(choice)
+		if choice == "" {
+			return
+		}
+
+		var choiceIndex int
+		if _, err := fmt.Sscanf(choice, "%d", &choiceIndex); err != nil || choiceIndex < 1 || choiceIndex > len(profiles) {
+			fmt.Println("✗ 无效的选择")
+			continue
+		}
+		useProfile(profiles[choiceIndex-1])
+		fmt.Println()
+		return
+	}
+}
+
+// parseSelection 解析隧道列表中的选择，如 "3"、"1,3,5"、"2-4" 或 "all"，返回从 0 开始的序号
+func parseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if strings.EqualFold(input, "all") {
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	var indexes []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		if !isRange {
+			hi = lo
+		}
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("无效的选择: %s", part)
+		}
+		for i := from; i <= to; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i-1)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("无效的选择")
+	}
+	return indexes, nil
+}
+
 // downloadFrpc 自动下载对应平台的 frpc
-func downloadFrpc(homeDir, configDir string) (string, error) {
-	nodeClient := api.NewNodeAPIClient()
-	downloadList, err := nodeClient.GetDownloadList()
+func downloadFrpc(ctx context.Context, configDir string) (string, error) {
+	nodeClient := newNodeClient()
+	downloadList, err := nodeClient.GetDownloadListContext(ctx)
 	if err != nil {
 		return "", fmt.Errorf("获取下载列表失败: %w", err)
 	}
 
-	if downloadList.Status != 200 || len(downloadList.Lists.Frpc) == 0 {
+	if len(downloadList.Lists.Frpc) == 0 {
 		return "", fmt.Errorf("未找到可用下载列表")
 	}
 
@@ -408,7 +566,11 @@ func downloadFrpc(homeDir, configDir string) (string, error) {
 
 	// 下载文件
 	fmt.Println("正在下载 frpc...")
-	resp, err := http.Get(downloadURL)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("创建下载请求失败: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
 		return "", fmt.Errorf("下载失败: %w", err)
 	}
@@ -583,6 +745,15 @@ func extractZip(src, dest string) error {
 	return fmt.Errorf("压缩包中未找到 frpc 文件")
 }
 
+// isCanceled 判断请求是否被 Ctrl+C 取消，是则提示并由调用方退出启动器
+func isCanceled(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("\n✗ 已取消")
+		return true
+	}
+	return false
+}
+
 // getFileExt 获取文件扩展名
 func getFileExt(url string) string {
 	if idx := strings.LastIndex(url, "."); idx != -1 {
@@ -593,23 +764,21 @@ func getFileExt(url string) string {
 
 func init() {
 	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(logoutCmd)
 }
 
 // logoutCmd 退出登录命令
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "退出登录",
-	Long:  `清除保存的登录状态`,
+	Long:  `清除当前账户保存的登录状态`,
 	Run: func(cmd *cobra.Command, args []string) {
-		homeDir, _ := os.UserHomeDir()
-		sessionFile := filepath.Join(homeDir, ".hayfrp", "session.json")
-
-		if _, err := os.Stat(sessionFile); os.IsNotExist(err) {
+		err := removeSession(currentProfile())
+		if errors.Is(err, os.ErrNotExist) {
 			fmt.Println("当前没有保存的登录状态")
 			return
 		}
-
-		if err := os.Remove(sessionFile); err != nil {
+		if err != nil {
 			fmt.Printf("✗ 退出登录失败: %v\n", err)
 			return
 		}
@@ -617,27 +786,3 @@ var logoutCmd = &cobra.Command{
 		fmt.Println("✓ 已退出登录")
 	},
 }
-
-// loadSession 加载保存的会话
-func loadSession(path string) *SavedSession {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil
-	}
-
-	var session SavedSession
-	if err := json.Unmarshal(data, &session); err != nil {
-		return nil
-	}
-
-	return &session
-}
-
-// saveSession 保存会话
-func saveSession(path string, session *SavedSession) error {
-	data, err := json.MarshalIndent(session, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0600)
-}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		input string
+		n     int
+		want  []int
+	}{
+		{"1", 3, []int{0}},
+		{"3,1", 3, []int{2, 0}},
+		{"1-3", 5, []int{0, 1, 2}},
+		{" 2 - 3 , 5 ", 5, []int{1, 2, 4}},
+		{"1,1,1-2,2", 3, []int{0, 1}},
+		{"1,,2,", 3, []int{0, 1}},
+		{"all", 3, []int{0, 1, 2}},
+		{"ALL", 2, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.input, tt.n)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSelection(%q, %d) = %v, %v，期望 %v", tt.input, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseSelectionInvalid(t *testing.T) {
+	for _, input := range []string{"", ",", "0", "4", "3-1", "1-4", "a", "1-", "-2", "1,x"} {
+		if got, err := parseSelection(input, 3); err == nil {
+			t.Errorf("parseSelection(%q, 3) = %v，期望返回错误", input, got)
+		}
+	}
+}
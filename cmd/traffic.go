@@ -0,0 +1,446 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// trafficSampleInterval 两次流量采样的最短间隔，间隔内的采样被忽略
+	trafficSampleInterval = time.Minute
+	// trafficRetention 流量采样的保留时长
+	trafficRetention = 90 * 24 * time.Hour
+	// defaultTrafficWatchInterval 隧道运行期间检查流量的默认间隔
+	defaultTrafficWatchInterval = 10 * time.Minute
+)
+
+// trafficSample 一次流量采样，按行保存在账户目录的 traffic.jsonl 中
+type trafficSample struct {
+	Time      time.Time `json:"time"`
+	Remaining api.Bytes `json:"remaining"`
+	Today     api.Bytes `json:"today"`
+	Total     api.Bytes `json:"total"`
+}
+
+func trafficFile(profile string) string {
+	return filepath.Join(profileDir(profile), "traffic.jsonl")
+}
+
+// readTrafficSamples 读取账户的流量采样，按时间先后排列，跳过无法解析的行
+func readTrafficSamples(profile string) ([]trafficSample, error) {
+	f, err := os.Open(trafficFile(profile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var samples []trafficSample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var sample trafficSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// recordTraffic 将用户信息中的流量追加到账户的流量采样中
+//
+// 距上次采样不足 trafficSampleInterval 时忽略；存在超过 trafficRetention 的
+// 旧采样时重写文件将其清理。
+func recordTraffic(profile string, info *api.UserInfo) error {
+	samples, err := readTrafficSamples(profile)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if n := len(samples); n > 0 && now.Sub(samples[n-1].Time) < trafficSampleInterval {
+		return nil
+	}
+
+	sample := trafficSample{
+		Time:      now,
+		Remaining: info.Traffic,
+		Today:     info.Todaytraffic,
+		Total:     info.Totaltraffic,
+	}
+
+	flag := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if len(samples) > 0 && now.Sub(samples[0].Time) > trafficRetention {
+		var kept []trafficSample
+		for _, s := range samples {
+			if now.Sub(s.Time) <= trafficRetention {
+				kept = append(kept, s)
+			}
+		}
+		samples = kept
+		flag = os.O_TRUNC | os.O_CREATE | os.O_WRONLY
+	} else {
+		samples = nil
+	}
+	samples = append(samples, sample)
+
+	if err := os.MkdirAll(profileDir(profile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(trafficFile(profile), flag, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, s := range samples {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// noteTraffic 使用保存的登录状态时记录流量采样，通过 --token 指定 Token 时不记录
+func noteTraffic(info *api.UserInfo) {
+	if viper.GetString("token") != "" {
+		return
+	}
+	profile := currentProfile()
+	if err := recordTraffic(profile, info); err != nil {
+		logger.Warn("保存流量记录失败", "profile", profile, "error", err)
+	}
+}
+
+// dailyUsage 某一天的流量用量
+type dailyUsage struct {
+	Day   time.Time
+	Used  api.Bytes
+	Valid bool // 当天是否有采样
+}
+
+// summarizeDaily 按本地日期汇总最近 days 天（含今天）的用量
+//
+// 每天的用量取当天采样中“今日使用流量”的最大值。
+func summarizeDaily(samples []trafficSample, days int, now time.Time) []dailyUsage {
+	today := clockOn(now.Local(), 0)
+	usage := make([]dailyUsage, days)
+	for i := range usage {
+		usage[i].Day = today.AddDate(0, 0, i-days+1)
+	}
+	for _, s := range samples {
+		day := clockOn(s.Time.Local(), 0)
+		i := days - 1 - int(math.Round(today.Sub(day).Hours()/24))
+		if i < 0 || i >= days {
+			continue
+		}
+		usage[i].Valid = true
+		usage[i].Used = max(usage[i].Used, s.Today)
+	}
+	return usage
+}
+
+// averageUsage 返回有采样的日期的日均用量，没有采样时 ok 为 false
+func averageUsage(usage []dailyUsage) (avg api.Bytes, ok bool) {
+	var total api.Bytes
+	var n int
+	for _, u := range usage {
+		if u.Valid {
+			total += u.Used
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return total / api.Bytes(n), true
+}
+
+// usageBar 按比例绘制用量条
+func usageBar(used, peak api.Bytes, width int) string {
+	if peak <= 0 || used <= 0 {
+		return ""
+	}
+	n := int(math.Ceil(float64(used) / float64(peak) * float64(width)))
+	return strings.Repeat("█", min(n, width))
+}
+
+var trafficCmd = &cobra.Command{
+	Use:   "traffic",
+	Short: "流量报告",
+	Long: `获取当前流量并记录，显示最近每天的用量、日均用量的变化趋势以及按日均用量
+预计剩余流量可用的天数。
+
+流量采样保存在账户目录的 traffic.jsonl 中，hayfrp user info / whoami 以及隧道运行期间
+都会记录采样，采样越多报告越准确。`,
+	Example: `  hayfrp user traffic
+  hayfrp user traffic --days 30`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 1 {
+			fmt.Println("✗ 天数至少为 1")
+			return
+		}
+
+		profile := currentProfile()
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newUserClient()
+		info, err := client.GetInfoContext(cmd.Context(), csrf)
+		if err != nil {
+			fmt.Printf("获取用户信息失败: %v\n", err)
+			return
+		}
+		noteTraffic(info)
+
+		var samples []trafficSample
+		if viper.GetString("token") == "" {
+			samples, err = readTrafficSamples(profile)
+			if err != nil {
+				fmt.Printf("✗ 读取流量记录失败: %v\n", err)
+				return
+			}
+		}
+
+		now := time.Now()
+		usage := summarizeDaily(samples, days, now)
+		// 今天的用量以刚获取的数据为准
+		usage[days-1].Used = max(usage[days-1].Used, info.Todaytraffic)
+		usage[days-1].Valid = true
+
+		var peak api.Bytes
+		for _, u := range usage {
+			peak = max(peak, u.Used)
+		}
+
+		fmt.Printf("========== 流量报告 (%s) ==========\n", profile)
+		fmt.Printf("剩余流量: %s\n", info.Traffic)
+		fmt.Printf("今日已用: %s\n", info.Todaytraffic)
+		fmt.Printf("\n最近 %d 天用量:\n", days)
+		for _, u := range usage {
+			if !u.Valid {
+				fmt.Printf("  %s  %-20s %s\n", u.Day.Format("01-02"), "", "无数据")
+				continue
+			}
+			fmt.Printf("  %s  %-20s %s\n", u.Day.Format("01-02"), usageBar(u.Used, peak, 20), u.Used)
+		}
+
+		// 今天尚未结束，有完整日期的数据时不计入日均用量
+		avg, ok := averageUsage(usage[:days-1])
+		if !ok {
+			avg, ok = averageUsage(usage)
+		}
+		if !ok || avg == 0 {
+			fmt.Println("\n日均用量: 0 B")
+			fmt.Println("预计可用: 暂无用量，无法预计")
+			fmt.Printf("================================\n")
+			return
+		}
+		fmt.Printf("\n日均用量: %s", avg)
+		// 与之前相同天数的日均用量比较
+		previous := summarizeDaily(samples, days, now.AddDate(0, 0, -days))
+		if prevAvg, ok := averageUsage(previous); ok && prevAvg > 0 {
+			change := (float64(avg) - float64(prevAvg)) / float64(prevAvg) * 100
+			switch {
+			case change >= 5:
+				fmt.Printf(" (较前 %d 天上升 %.0f%%)", days, change)
+			case change <= -5:
+				fmt.Printf(" (较前 %d 天下降 %.0f%%)", days, -change)
+			default:
+				fmt.Printf(" (与前 %d 天持平)", days)
+			}
+		}
+		fmt.Println()
+
+		remainingDays := float64(info.Traffic) / float64(avg)
+		exhaust := now.Add(time.Duration(remainingDays * float64(24*time.Hour)))
+		fmt.Printf("预计可用: 约 %.1f 天 (%s 前后用完)\n", remainingDays, exhaust.Format(time.DateOnly))
+		if threshold, ok := trafficThreshold(); ok && info.Traffic < threshold {
+			fmt.Printf("  ! 剩余流量低于告警阈值 %s\n", threshold)
+		}
+		fmt.Printf("================================\n")
+	},
+}
+
+// trafficThreshold 返回配置的流量告警阈值 traffic.alert.threshold，未配置或无效时 ok 为 false
+func trafficThreshold() (api.Bytes, bool) {
+	value := viper.GetString("traffic.alert.threshold")
+	if value == "" {
+		return 0, false
+	}
+	threshold, err := api.ParseBytes(value)
+	if err != nil {
+		logger.Warn("忽略无效的流量告警阈值", "threshold", value, "error", err)
+		return 0, false
+	}
+	return threshold, threshold > 0
+}
+
+// trafficAlert 剩余流量低于阈值时发送的告警
+type trafficAlert struct {
+	Profile   string    `json:"profile"`
+	Username  string    `json:"username"`
+	Remaining api.Bytes `json:"remaining"`
+	Threshold api.Bytes `json:"threshold"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// sendTrafficAlert 通过 stderr、traffic.alert.webhook 和 traffic.alert.exec 发送告警
+//
+// webhook 以 JSON 格式 POST 告警内容；exec 通过 shell 执行，告警内容以
+// HAYFRP_ALERT_* 环境变量传入。
+func sendTrafficAlert(ctx context.Context, alert trafficAlert) {
+	fmt.Fprintf(os.Stderr, "\n⚠ %s\n", alert.Message)
+
+	if webhook := viper.GetString("traffic.alert.webhook"); webhook != "" {
+		if err := postTrafficAlert(ctx, webhook, alert); err != nil {
+			logger.Warn("发送流量告警 webhook 失败", "url", webhook, "error", err)
+		}
+	}
+
+	if hook := viper.GetString("traffic.alert.exec"); hook != "" {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		var c *exec.Cmd
+		if runtime.GOOS == "windows" {
+			c = exec.CommandContext(ctx, "cmd", "/C", hook)
+		} else {
+			c = exec.CommandContext(ctx, "sh", "-c", hook)
+		}
+		c.Env = append(os.Environ(),
+			"HAYFRP_ALERT_PROFILE="+alert.Profile,
+			"HAYFRP_ALERT_USERNAME="+alert.Username,
+			fmt.Sprintf("HAYFRP_ALERT_REMAINING=%d", alert.Remaining),
+			fmt.Sprintf("HAYFRP_ALERT_THRESHOLD=%d", alert.Threshold),
+			"HAYFRP_ALERT_MESSAGE="+alert.Message,
+		)
+		c.Stdout = os.Stderr
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			logger.Warn("执行流量告警命令失败", "command", hook, "error", err)
+		}
+	}
+}
+
+func postTrafficAlert(ctx context.Context, url string, alert trafficAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// watchTraffic 隧道运行期间定期获取用户信息并记录流量，直到 ctx 被取消
+//
+// 配置了 traffic.alert.threshold 时，剩余流量低于阈值会发送一次告警，
+// 恢复到阈值以上（如签到后）再次低于阈值时重新告警。
+//
+// 每次采样都从保存的会话读取当前 Token，与 start 等同时运行的调用方
+// 刷新或重新登录后立即使用新 Token，不会各自重新登录导致对方的 Token 失效。
+func watchTraffic(ctx context.Context, profile string) {
+	interval := viper.GetDuration("traffic.alert.interval")
+	if interval <= 0 {
+		interval = defaultTrafficWatchInterval
+	}
+	client := newProfileUserClient(profile, nil)
+
+	alerted := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		info, err := sampleTraffic(ctx, client, profile)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Warn("获取流量信息失败", "profile", profile, "error", err)
+		} else {
+			if err := recordTraffic(profile, info); err != nil {
+				logger.Warn("保存流量记录失败", "profile", profile, "error", err)
+			}
+			threshold, ok := trafficThreshold()
+			switch {
+			case ok && info.Traffic < threshold && !alerted:
+				alerted = true
+				sendTrafficAlert(ctx, trafficAlert{
+					Profile:   profile,
+					Username:  info.Username,
+					Remaining: info.Traffic,
+					Threshold: threshold,
+					Message:   fmt.Sprintf("账户 %s (%s) 剩余流量 %s，低于告警阈值 %s", profile, info.Username, info.Traffic, threshold),
+					Time:      time.Now(),
+				})
+			case ok && info.Traffic >= threshold:
+				alerted = false
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleTraffic 使用保存的会话中的当前 Token 获取用户信息
+func sampleTraffic(ctx context.Context, client *api.UserAPIClient, profile string) (*api.UserInfo, error) {
+	session, err := loadSession(profile)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.CSRF == "" {
+		return nil, errNotLoggedIn
+	}
+	return client.GetInfoContext(ctx, session.CSRF)
+}
+
+func init() {
+	userCmd.AddCommand(trafficCmd)
+
+	trafficCmd.Flags().Int("days", 7, "统计最近的天数")
+}
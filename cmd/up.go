@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up <proxy-id...>",
+	Short: "启动指定的隧道（非交互式）",
+	Long: `按隧道ID启动一个或多个隧道，all 表示启动全部隧道。
+
+同一节点的隧道合并到一个配置文件中，由一个 frpc 运行；不同节点各运行一个 frpc。
+配置文件按节点和隧道ID保存在账户目录的 tunnels/ 下，同时运行的多个 hayfrp 互不覆盖。
+frpc 意外退出后自动重启，按 Ctrl+C 停止全部隧道。
+
+-d 将隧道交给后台的守护进程运行，关闭终端后继续运行，
+之后通过 hayfrp ps / stop / restart / logs 管理。`,
+	Example: `  hayfrp up 12
+  hayfrp up 12 15 18
+  hayfrp up all --profile work
+  hayfrp up -d 12 15`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+		profile := currentProfile()
+
+		ctx, stop := interruptContext(cmd.Context())
+		defer stop()
+
+		client := newProxyClient()
+		listResp, err := client.ListTunnelContext(ctx, csrf, "")
+		if err != nil {
+			fmt.Printf("✗ 获取隧道列表失败: %v\n", err)
+			return
+		}
+
+		selected, err := selectTunnels(listResp.Proxies, args)
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		if err := enableTunnels(ctx, client, csrf, selected); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		groups, err := prepareTunnels(ctx, client, csrf, profile, selected, listResp.Proxies)
+		if err != nil {
+			fmt.Printf("✗ 生成配置文件失败: %v\n", err)
+			return
+		}
+
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			startDetached(ctx, profile, groups)
+			return
+		}
+
+		frpcPath := locateFrpc()
+		if frpcPath == "" {
+			fmt.Println("未找到 frpc 可执行文件，正在尝试自动下载...")
+			if err := os.MkdirAll(hayfrpDir(), 0755); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			frpcPath, err = downloadFrpc(ctx, hayfrpDir())
+			if err != nil {
+				fmt.Printf("✗ 自动下载 frpc 失败: %v\n", err)
+				return
+			}
+		}
+
+		for _, group := range groups {
+			names := make([]string, len(group.Tunnels))
+			for i, t := range group.Tunnels {
+				names[i] = t.ProxyName
+			}
+			fmt.Printf("✓ %s: %s (%s)\n", group.NodeName, strings.Join(names, ", "), group.File)
+		}
+		fmt.Printf("使用 frpc: %s，按 Ctrl+C 停止\n\n", frpcPath)
+
+		// 通过 --token 指定 Token 时没有可记录流量的账户
+		if viper.GetString("token") == "" {
+			go watchTraffic(ctx, profile)
+		}
+		if err := runTunnelGroups(ctx, frpcPath, groups); err != nil {
+			fmt.Printf("\n✗ frpc 运行失败: %v\n", err)
+			return
+		}
+		fmt.Println("\n✓ 隧道已停止")
+	},
+}
+
+// startDetached 将隧道组交给守护进程运行，守护进程未运行时先在后台启动
+func startDetached(ctx context.Context, profile string, groups []tunnelGroup) {
+	if err := ensureDaemon(ctx); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+
+	body := daemonRequestBody{}
+	for _, group := range groups {
+		body.Tunnels = append(body.Tunnels, newDaemonTunnel(profile, group))
+	}
+	var resp daemonStartResponse
+	if err := daemonCall(ctx, http.MethodPost, "/start", body, &resp); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+	for _, t := range body.Tunnels {
+		if slices.Contains(resp.Running, t.ID) {
+			fmt.Printf("• %s: %s 已在运行，配置有变更时执行 hayfrp restart %s\n", t.ID, t.tunnelNames(), t.ID)
+			continue
+		}
+		fmt.Printf("✓ %s: %s (%s)\n", t.ID, t.tunnelNames(), t.NodeName)
+	}
+	fmt.Println("隧道已在后台运行，使用 hayfrp ps 查看状态，hayfrp logs <id> 查看日志")
+}
+
+// selectTunnels 按隧道ID从隧道列表中选出隧道，ids 为 all 时返回全部隧道
+func selectTunnels(tunnels []api.TunnelInfo, ids []string) ([]api.TunnelInfo, error) {
+	if len(ids) == 1 && strings.EqualFold(ids[0], "all") {
+		if len(tunnels) == 0 {
+			return nil, errors.New("暂无隧道，请先在控制台创建隧道")
+		}
+		return tunnels, nil
+	}
+
+	byID := make(map[string]api.TunnelInfo, len(tunnels))
+	for _, t := range tunnels {
+		byID[t.ID] = t
+	}
+	var selected []api.TunnelInfo
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		t, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("未找到隧道 %s", id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			selected = append(selected, t)
+		}
+	}
+	return selected, nil
+}
+
+func init() {
+	rootCmd.AddCommand(upCmd)
+
+	upCmd.Flags().BoolP("detach", "d", false, "交给后台的守护进程运行")
+}
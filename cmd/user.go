@@ -1,13 +1,16 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
-	"strconv"
+	"io"
+	"os"
+	"strings"
 	"syscall"
-
-	"hayfrp-cli/api"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"golang.org/x/term"
 )
 
@@ -20,246 +23,366 @@ var userCmd = &cobra.Command{
 var loginCmd = &cobra.Command{
 	Use:   "login [username]",
 	Short: "用户登录",
-	Args:  cobra.ExactArgs(1),
+	Long: `登录并保存登录状态，之后的 user / proxy 命令及 hayfrp start 会自动使用该账户。
+
+密码按以下顺序获取，便于在脚本或 CI 中使用：
+  1. --password-file 指定的文件
+  2. HAYFRP_PASSWORD 环境变量
+  3. 标准输入（非终端时，如 echo "$PASS" | hayfrp user login alice）
+  4. 交互式输入`,
+	Example: `  hayfrp user login alice
+  hayfrp user login alice --password-file /run/secrets/hayfrp
+  hayfrp user login bob --profile work --remember < password.txt`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		user := args[0]
 
-		fmt.Print("请输入密码: ")
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+		passwd, err := readLoginPassword(cmd)
 		if err != nil {
-			fmt.Printf("\n读取密码失败: %v\n", err)
+			fmt.Printf("读取密码失败: %v\n", err)
+			return
+		}
+		if passwd == "" {
+			fmt.Println("✗ 密码不能为空")
 			return
 		}
-		fmt.Println() // 换行
-		passwd := string(bytePassword)
 
-		client := api.NewUserAPIClient()
-		resp, err := client.Login(user, passwd)
+		client := newUserClient()
+		resp, err := client.LoginContext(cmd.Context(), user, passwd)
 		if err != nil {
 			fmt.Printf("登录失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ 登录成功！\n")
+		remember, _ := cmd.Flags().GetBool("remember")
+		session, err := saveLogin(user, passwd, resp.Token, remember)
+		if err != nil {
+			fmt.Printf("✗ 登录成功，但保存登录状态失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("✓ 登录成功！(已保存登录状态: %s，Token 存储: %s)\n", sessionFilePath(), session.Store)
+		if printToken, _ := cmd.Flags().GetBool("print-token"); printToken {
 			fmt.Printf("  Token: %s\n", resp.Token)
-		} else {
-			fmt.Printf("✗ 登录失败: %s\n", resp.Message)
 		}
 	},
 }
 
+// readLoginPassword 按 --password-file、HAYFRP_PASSWORD、标准输入、交互输入的顺序读取密码
+func readLoginPassword(cmd *cobra.Command) (string, error) {
+	return readPasswordFrom(cmd, func() (string, error) {
+		return readPasswordWithMask("请输入密码")
+	})
+}
+
+// readPasswordFrom 按 --password-file、HAYFRP_PASSWORD、标准输入（非终端时）的顺序读取密码，
+// 都未提供时调用 prompt 交互输入
+func readPasswordFrom(cmd *cobra.Command, prompt func() (string, error)) (string, error) {
+	if path, _ := cmd.Flags().GetString("password-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if passwd := os.Getenv("HAYFRP_PASSWORD"); passwd != "" {
+		return passwd, nil
+	}
+	if !term.IsTerminal(int(syscall.Stdin)) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	return prompt()
+}
+
 var verifyCsrfCmd = &cobra.Command{
 	Use:   "verify [csrf]",
 	Short: "验证Token是否有效",
-	Args:  cobra.ExactArgs(1),
+	Long:  `验证Token是否有效，未指定时验证当前使用的Token`,
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
+		var csrf string
+		if len(args) > 0 {
+			csrf = args[0]
+		} else {
+			var err error
+			if csrf, err = resolveToken(); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+		}
 
-		client := api.NewUserAPIClient()
-		resp, err := client.VerifyCsrf(csrf)
+		client := newUserClient()
+		resp, err := client.VerifyCsrfContext(cmd.Context(), csrf)
 		if err != nil {
 			fmt.Printf("验证失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ Token有效\n")
-			fmt.Printf("  Token: %s\n", resp.Token)
-		} else {
-			fmt.Printf("✗ Token无效: %s\n", resp.Message)
-		}
+		fmt.Printf("✓ Token有效\n")
+		fmt.Printf("  Token: %s\n", resp.Token)
 	},
 }
 
 var userInfoCmd = &cobra.Command{
-	Use:   "info [csrf]",
+	Use:   "info",
 	Short: "获取用户信息",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
 
-		client := api.NewUserAPIClient()
-		resp, err := client.GetInfo(csrf)
+		client := newUserClient()
+		resp, err := client.GetInfoContext(cmd.Context(), csrf)
 		if err != nil {
 			fmt.Printf("获取用户信息失败: %v\n", err)
 			return
 		}
+		noteTraffic(resp)
+
+		fmt.Printf("========== 用户信息 ==========\n")
+		fmt.Printf("用户ID: %d\n", resp.ID)
+		fmt.Printf("用户名: %s\n", resp.Username)
+		fmt.Printf("邮箱: %s\n", resp.Email)
+		fmt.Printf("剩余流量: %s\n", resp.Traffic)
+		fmt.Printf("今日使用流量: %s\n", resp.Todaytraffic)
+		fmt.Printf("拥有隧道数: %d\n", resp.Proxies)
+		fmt.Printf("已使用隧道: %d\n", resp.Useproxies)
+		fmt.Printf("是否实名: %s\n", yesNo(resp.Realname))
+		fmt.Printf("是否服务商: %s\n", yesNo(resp.Sprovider))
+		if !resp.Regtime.IsZero() {
+			fmt.Printf("注册时间: %s\n", resp.Regtime.Local().Format(time.DateTime))
+		}
 
-		if resp.Status {
-			fmt.Printf("========== 用户信息 ==========\n")
-			fmt.Printf("用户ID: %v\n", resp.ID)
-			fmt.Printf("用户名: %s\n", resp.Username)
-			fmt.Printf("邮箱: %s\n", resp.Email)
-			// 转换流量为GB
-			var trafficGB float64
-			switch v := resp.Traffic.(type) {
-			case string:
-				if val, err := strconv.ParseFloat(v, 64); err == nil {
-					trafficGB = val / 1024
-				}
-			case float64:
-				trafficGB = v / 1024
-			}
-			fmt.Printf("剩余流量: %.2f GB\n", trafficGB)
-			fmt.Printf("今日使用流量: %v Bytes\n", resp.Todaytraffic)
-			fmt.Printf("拥有隧道数: %v\n", resp.Proxies)
-			fmt.Printf("已使用隧道: %v\n", resp.Useproxies)
-
-			// 处理可能为 string 或 bool 的字段
-			fmt.Printf("是否实名: %v\n", resp.Realname)
-			fmt.Printf("是否服务商: %v\n", resp.Sprovider)
-
-			fmt.Printf("UUID: %s\n", resp.UUID)
-			fmt.Printf("Token: %s\n", resp.Token)
-			if resp.Signdate != "" && resp.Signdate != "null" {
-				fmt.Printf("上次签到时间: %s\n", resp.Signdate)
-				fmt.Printf("总签到天数: %v\n", resp.Totalsign)
-				fmt.Printf("总签到流量: %v GB\n", resp.Totaltraffic)
+		fmt.Printf("UUID: %s\n", resp.UUID)
+		fmt.Printf("Token: %s\n", resp.Token)
+		if !resp.Signdate.IsZero() {
+			fmt.Printf("上次签到时间: %s\n", resp.Signdate.Local().Format(time.DateTime))
+			fmt.Printf("总签到天数: %d\n", resp.Totalsign)
+			fmt.Printf("总签到流量: %s\n", resp.Totaltraffic)
+		}
+	},
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "查看当前登录的账户",
+	Long:  `显示当前使用的账户、登录状态和剩余流量，并在Token有效时更新最近验证时间`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile := currentProfile()
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newUserClient()
+		info, infoErr := client.GetInfoContext(cmd.Context(), csrf)
+
+		fmt.Printf("========== 当前账户 ==========\n")
+		fmt.Printf("账户: %s\n", profile)
+
+		// 通过 --token / HAYFRP_TOKEN 指定时没有保存的会话信息
+		var session *SavedSession
+		if viper.GetString("token") == "" {
+			if infoErr == nil {
+				touchSession(profile)
+				noteTraffic(info)
 			}
+			session = readSessionFile(sessionFile(profile))
 		} else {
-			fmt.Printf("获取用户信息失败: %s\n", resp.Message)
+			fmt.Println("Token来源: --token / HAYFRP_TOKEN")
+		}
+
+		if session != nil {
+			fmt.Printf("用户名: %s\n", session.Username)
+		} else if infoErr == nil {
+			fmt.Printf("用户名: %s\n", info.Username)
 		}
+
+		if session != nil {
+			if session.Endpoint != "" {
+				fmt.Printf("API端点: %s\n", session.Endpoint)
+			}
+			fmt.Printf("登录时间: %s (%s)\n", session.LoginTime.Local().Format("2006-01-02 15:04:05"), formatAgo(session.LoginTime))
+			if session.stale() {
+				fmt.Printf("  ! 已超过最长有效期 %s，启动器将主动重新登录\n", sessionMaxAge())
+			}
+			if !session.LastVerified.IsZero() {
+				fmt.Printf("最近验证: %s (%s)\n", session.LastVerified.Local().Format("2006-01-02 15:04:05"), formatAgo(session.LastVerified))
+			}
+			fmt.Printf("Token存储: %s\n", session.Store)
+			if session.AutoRelogin {
+				fmt.Println("自动重新登录: 已开启")
+			} else {
+				fmt.Println("自动重新登录: 未开启")
+			}
+		}
+
+		if infoErr != nil {
+			fmt.Printf("✗ 获取用户信息失败: %v\n", infoErr)
+			return
+		}
+		fmt.Printf("剩余流量: %s\n", info.Traffic)
 	},
 }
 
+// formatAgo 将时间格式化为“3天2小时前”这样的相对时间
+func formatAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "刚刚"
+	case d < time.Hour:
+		return fmt.Sprintf("%d分钟前", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d小时%d分钟前", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%d天%d小时前", int(d.Hours())/24, int(d.Hours())%24)
+}
+
 var signCmd = &cobra.Command{
-	Use:   "sign [csrf]",
+	Use:   "sign",
 	Short: "每日签到",
-	Args:  cobra.ExactArgs(1),
+	Long: `签到领取流量。
+
+使用 --auto 持续运行，每天在 --at 指定的时间（配置 sign.time）之后的随机延迟
+（--jitter，配置 sign.jitter）内为已登录的账户签到，每个账户单独抽取随机延迟，
+当天已签到的账户自动跳过。
+通过 --profile 或配置 sign.profiles 可以限定签到的账户。`,
+	Example: `  hayfrp user sign
+  hayfrp user sign --auto --at 08:30 --jitter 1h
+  hayfrp user sign history`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
+		if auto, _ := cmd.Flags().GetBool("auto"); auto {
+			startAutoSign(cmd)
+			return
+		}
 
-		client := api.NewUserAPIClient()
-		resp, err := client.Sign(csrf)
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		client := newUserClient()
+		resp, err := client.SignContext(cmd.Context(), csrf)
 		if err != nil {
 			fmt.Printf("签到失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-			fmt.Printf("  签到获得流量: %.2f GB\n", resp.Signflow)
-			fmt.Printf("  剩余流量: %.2f GB\n", resp.Flow)
-		} else {
-			fmt.Printf("✗ 签到失败: %s\n", resp.Message)
+		fmt.Printf("✓ %s\n", resp.Message)
+		fmt.Printf("  签到获得流量: %.2f GB\n", resp.Signflow)
+		fmt.Printf("  剩余流量: %.2f GB\n", resp.Flow)
+
+		// 通过 --token 指定的 Token 不属于任何已保存的账户，不记录
+		if viper.GetString("token") == "" {
+			profile := currentProfile()
+			var username string
+			if session := readSessionFile(sessionFile(profile)); session != nil {
+				username = session.Username
+			}
+			if err := recordSign(profile, username, resp); err != nil {
+				logger.Warn("保存签到记录失败", "profile", profile, "error", err)
+			}
 		}
 	},
 }
 
 var retokenCmd = &cobra.Command{
-	Use:   "retoken [csrf]",
+	Use:   "retoken",
 	Short: "更新用户Token",
-	Args:  cobra.ExactArgs(1),
+	Long: `同时重置 frp 连接 Token 和登录 Token（csrf），原 Token 随即失效。
+
+使用保存的登录状态时自动将新 Token 写回；通过 --token / HAYFRP_TOKEN 指定时需手动替换。`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		csrf := args[0]
+		csrf, err := resolveToken()
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
 
-		client := api.NewUserAPIClient()
-		resp, err := client.ReToken(csrf)
+		client := newUserClient()
+		resp, err := client.ReTokenContext(cmd.Context(), csrf)
 		if err != nil {
 			fmt.Printf("更新Token失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-			fmt.Printf("  新Token: %s\n", resp.Token)
-		} else {
-			fmt.Printf("✗ 更新Token失败: %s\n", resp.Message)
+		fmt.Printf("✓ %s\n", resp.Message)
+		fmt.Printf("  新Token: %s\n", resp.Token)
+
+		// 更新后原登录 Token 失效
+		if viper.GetString("token") != "" {
+			fmt.Println("⚠ 通过 --token / HAYFRP_TOKEN 指定的Token已失效，请替换为新Token")
+			return
 		}
+		profile := currentProfile()
+		session, err := loadSession(profile)
+		if err != nil {
+			fmt.Printf("⚠ 读取保存的登录状态失败，请重新登录: %v\n", err)
+			return
+		}
+		if session == nil {
+			fmt.Println("⚠ 保存的登录状态已不存在，请重新登录")
+			return
+		}
+		session.CSRF = resp.Token
+		session.LastVerified = time.Now()
+		if err := saveSession(profile, session); err != nil {
+			fmt.Printf("⚠ 保存新Token失败，请重新登录: %v\n", err)
+			return
+		}
+		fmt.Println("✓ 已更新保存的登录状态")
 	},
 }
 
 var sendRegCodeCmd = &cobra.Command{
 	Use:   "send-reg [username] [email]",
 	Short: "发送注册验证码",
+	Long:  `只发送注册验证码，完整的注册流程请使用 hayfrp user register`,
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		user := args[0]
 		email := args[1]
 
-		client := api.NewUserAPIClient()
-		resp, err := client.SendRegCode(user, user+"@"+email, email)
+		client := newUserClient()
+		resp, err := client.SendRegCodeContext(cmd.Context(), user, deviceName(), email)
 		if err != nil {
 			fmt.Printf("发送验证码失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 发送验证码失败: %s\n", resp.Message)
-		}
-	},
-}
-
-var registerCmd = &cobra.Command{
-	Use:   "register [username] [email] [password] [code]",
-	Short: "用户注册",
-	Args:  cobra.ExactArgs(4),
-	Run: func(cmd *cobra.Command, args []string) {
-		user := args[0]
-		email := args[1]
-		passwd := args[2]
-		code := args[3]
-
-		client := api.NewUserAPIClient()
-		resp, err := client.Register(user, user+"@"+email, email, passwd, code)
-		if err != nil {
-			fmt.Printf("注册失败: %v\n", err)
-			return
-		}
-
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 注册失败: %s\n", resp.Message)
-		}
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
 var sendFindPassCodeCmd = &cobra.Command{
 	Use:   "send-findpass [username]",
 	Short: "发送重置密码验证码",
+	Long:  `只发送重置密码邮件，完整的重置流程请使用 hayfrp user reset-password`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		user := args[0]
 
-		client := api.NewUserAPIClient()
-		resp, err := client.SendFindPassCode(user)
+		client := newUserClient()
+		resp, err := client.SendFindPassCodeContext(cmd.Context(), user)
 		if err != nil {
 			fmt.Printf("发送验证码失败: %v\n", err)
 			return
 		}
 
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 发送验证码失败: %s\n", resp.Message)
-		}
-	},
-}
-
-var resetPassCmd = &cobra.Command{
-	Use:   "reset-pass [token] [new-password]",
-	Short: "重置密码",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		token := args[0]
-		newpass := args[1]
-
-		client := api.NewUserAPIClient()
-		resp, err := client.ResetPass(token, newpass)
-		if err != nil {
-			fmt.Printf("重置密码失败: %v\n", err)
-			return
-		}
-
-		if resp.Status == 200 {
-			fmt.Printf("✓ %s\n", resp.Message)
-		} else {
-			fmt.Printf("✗ 重置密码失败: %s\n", resp.Message)
-		}
+		fmt.Printf("✓ %s\n", resp.Message)
 	},
 }
 
@@ -268,10 +391,14 @@ func init() {
 	userCmd.AddCommand(loginCmd)
 	userCmd.AddCommand(verifyCsrfCmd)
 	userCmd.AddCommand(userInfoCmd)
+	userCmd.AddCommand(whoamiCmd)
 	userCmd.AddCommand(signCmd)
 	userCmd.AddCommand(retokenCmd)
 	userCmd.AddCommand(sendRegCodeCmd)
-	userCmd.AddCommand(registerCmd)
 	userCmd.AddCommand(sendFindPassCodeCmd)
-	userCmd.AddCommand(resetPassCmd)
+
+	// login flags
+	loginCmd.Flags().String("password-file", "", "从文件读取密码")
+	loginCmd.Flags().Bool("print-token", false, "登录成功后输出Token")
+	loginCmd.Flags().Bool("remember", false, "保存密码，登录过期后自动重新登录")
 }
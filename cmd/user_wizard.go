@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"hayfrp-cli/api"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// 注册信息的本地校验规则，不满足时无需请求API即可提示
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,20}$`)
+	codePattern     = regexp.MustCompile(`^[A-Za-z0-9]{4,12}$`)
+)
+
+// minPasswordLength 密码最短长度
+const minPasswordLength = 6
+
+var registerCmd = &cobra.Command{
+	Use:   "register",
+	Short: "注册账户",
+	Long: `按步骤输入用户名、邮箱、邮箱验证码和密码完成注册，注册成功后自动登录并保存登录状态。
+验证码发送到邮箱，设备名称使用本机主机名。
+
+在脚本中注册时先用 hayfrp user send-reg 发送验证码，再通过 --user、--email、--code
+指定注册信息，密码与 user login 相同，按以下顺序获取：
+  1. --password-file 指定的文件
+  2. HAYFRP_PASSWORD 环境变量
+  3. 标准输入（非终端时）
+  4. 交互式输入`,
+	Example: `  hayfrp user register
+  hayfrp user register --profile work --remember
+  hayfrp user send-reg alice alice@example.com
+  hayfrp user register --user alice --email alice@example.com --code 123456 --password-file pass.txt`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("code") {
+			registerWithFlags(cmd)
+			return
+		}
+		if !term.IsTerminal(int(syscall.Stdin)) {
+			fmt.Println("✗ 注册向导需要在终端中交互运行，在脚本中请使用 --user、--email、--code 参数")
+			return
+		}
+		ctx := cmd.Context()
+		reader := bufio.NewReader(os.Stdin)
+		client := newUserClient()
+
+		fmt.Println("========== 注册账户 ==========")
+		user, err := promptInput(reader, "用户名 (3-20位字母、数字或下划线)", validateUsername)
+		if err != nil {
+			fmt.Printf("✗ %v\n", err)
+			return
+		}
+
+		// 步骤1: 发送邮箱验证码，发送失败时可更换邮箱重试
+		var email string
+		for {
+			if email, err = promptInput(reader, "邮箱", validateEmail); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			fmt.Print("正在发送验证码... ")
+			resp, err := client.SendRegCodeContext(ctx, user, deviceName(), email)
+			if err == nil {
+				fmt.Println("成功")
+				fmt.Printf("✓ %s\n", resp.Message)
+				break
+			}
+			fmt.Println("失败")
+			fmt.Printf("✗ 发送验证码失败: %v\n", err)
+			if !promptYes(reader, "重新输入邮箱?") {
+				return
+			}
+		}
+
+		// 步骤2: 输入验证码和密码，验证码有误时可重新输入
+		passwd, err := promptNewPassword()
+		if err != nil {
+			fmt.Printf("读取密码失败: %v\n", err)
+			return
+		}
+		for {
+			code, err := promptInput(reader, "邮箱验证码", validateCode)
+			if err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			resp, err := client.RegisterContext(ctx, user, deviceName(), email, passwd, code)
+			if err == nil {
+				fmt.Printf("✓ %s\n", resp.Message)
+				break
+			}
+			fmt.Printf("✗ 注册失败: %v\n", err)
+			if !promptYes(reader, "重新输入验证码?") {
+				return
+			}
+		}
+
+		// 步骤3: 自动登录
+		remember, _ := cmd.Flags().GetBool("remember")
+		loginAfterWizard(ctx, client, user, passwd, remember)
+	},
+}
+
+var resetPasswordCmd = &cobra.Command{
+	Use:     "reset-password",
+	Aliases: []string{"reset-pass"},
+	Short:   "重置密码",
+	Long: `输入用户名或邮箱后向账户邮箱发送重置Token，按步骤输入邮件中的Token和新密码完成重置，
+重置成功后自动登录并保存登录状态。
+
+在脚本中重置时先用 hayfrp user send-findpass 发送重置邮件，再通过 --reset-token 指定邮件中的Token，
+新密码的获取顺序与 user login 相同（--password-file、HAYFRP_PASSWORD、标准输入、交互输入）。
+同时指定 --user 时重置后自动登录。`,
+	Example: `  hayfrp user reset-password
+  hayfrp user reset-password --remember
+  hayfrp user send-findpass alice
+  HAYFRP_PASSWORD=... hayfrp user reset-password --reset-token <token> --user alice`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.Flags().Changed("reset-token") {
+			resetPasswordWithFlags(cmd)
+			return
+		}
+		if !term.IsTerminal(int(syscall.Stdin)) {
+			fmt.Println("✗ 重置密码向导需要在终端中交互运行，在脚本中请使用 --reset-token 参数")
+			return
+		}
+		ctx := cmd.Context()
+		reader := bufio.NewReader(os.Stdin)
+		client := newUserClient()
+
+		fmt.Println("========== 重置密码 ==========")
+
+		// 步骤1: 发送重置Token
+		var user string
+		var err error
+		for {
+			if user, err = promptInput(reader, "用户名/邮箱", validateAccount); err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			fmt.Print("正在发送重置邮件... ")
+			resp, err := client.SendFindPassCodeContext(ctx, user)
+			if err == nil {
+				fmt.Println("成功")
+				fmt.Printf("✓ %s\n", resp.Message)
+				break
+			}
+			fmt.Println("失败")
+			fmt.Printf("✗ 发送重置邮件失败: %v\n", err)
+			if !promptYes(reader, "重新输入用户名/邮箱?") {
+				return
+			}
+		}
+
+		// 步骤2: 输入邮件中的Token和新密码，Token 无效时可重新输入
+		passwd, err := promptNewPassword()
+		if err != nil {
+			fmt.Printf("读取密码失败: %v\n", err)
+			return
+		}
+		for {
+			token, err := promptInput(reader, "邮件中的重置Token", validateRequired)
+			if err != nil {
+				fmt.Printf("✗ %v\n", err)
+				return
+			}
+			// 成功提示中会回显新密码，不直接输出
+			if _, err = client.ResetPassContext(ctx, token, passwd); err == nil {
+				fmt.Println("✓ 密码重置成功")
+				break
+			}
+			fmt.Printf("✗ 重置密码失败: %v\n", err)
+			if !promptYes(reader, "重新输入Token?") {
+				return
+			}
+		}
+
+		// 步骤3: 使用新密码登录
+		remember, _ := cmd.Flags().GetBool("remember")
+		loginAfterWizard(ctx, client, user, passwd, remember)
+	},
+}
+
+// registerWithFlags 按命令行参数注册，不进行交互（密码未通过文件、环境变量或标准输入提供时除外）
+func registerWithFlags(cmd *cobra.Command) {
+	user, _ := cmd.Flags().GetString("user")
+	email, _ := cmd.Flags().GetString("email")
+	code, _ := cmd.Flags().GetString("code")
+	for _, check := range []struct {
+		flag string
+		err  error
+	}{
+		{"--user", validateUsername(user)},
+		{"--email", validateEmail(email)},
+		{"--code", validateCode(code)},
+	} {
+		if check.err != nil {
+			fmt.Printf("✗ %s: %v\n", check.flag, check.err)
+			return
+		}
+	}
+
+	passwd, err := readPasswordFrom(cmd, promptNewPassword)
+	if err != nil {
+		fmt.Printf("读取密码失败: %v\n", err)
+		return
+	}
+	if err := validatePassword(passwd); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+
+	ctx := cmd.Context()
+	client := newUserClient()
+	resp, err := client.RegisterContext(ctx, user, deviceName(), email, passwd, code)
+	if err != nil {
+		fmt.Printf("注册失败: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ %s\n", resp.Message)
+
+	remember, _ := cmd.Flags().GetBool("remember")
+	loginAfterWizard(ctx, client, user, passwd, remember)
+}
+
+// resetPasswordWithFlags 按命令行参数重置密码，指定了 --user 时重置后登录
+func resetPasswordWithFlags(cmd *cobra.Command) {
+	token, _ := cmd.Flags().GetString("reset-token")
+	user, _ := cmd.Flags().GetString("user")
+	if err := validateRequired(token); err != nil {
+		fmt.Printf("✗ --reset-token: %v\n", err)
+		return
+	}
+
+	passwd, err := readPasswordFrom(cmd, promptNewPassword)
+	if err != nil {
+		fmt.Printf("读取密码失败: %v\n", err)
+		return
+	}
+	if err := validatePassword(passwd); err != nil {
+		fmt.Printf("✗ %v\n", err)
+		return
+	}
+
+	ctx := cmd.Context()
+	client := newUserClient()
+	// 成功提示中会回显新密码，不直接输出
+	if _, err := client.ResetPassContext(ctx, token, passwd); err != nil {
+		fmt.Printf("重置密码失败: %v\n", err)
+		return
+	}
+	fmt.Println("✓ 密码重置成功")
+
+	if user == "" {
+		fmt.Println("使用 hayfrp user login <用户名> 登录")
+		return
+	}
+	remember, _ := cmd.Flags().GetBool("remember")
+	loginAfterWizard(ctx, client, user, passwd, remember)
+}
+
+// loginAfterWizard 注册或重置密码成功后登录并保存登录状态
+func loginAfterWizard(ctx context.Context, client *api.UserAPIClient, user, passwd string, remember bool) {
+	fmt.Print("正在登录... ")
+	resp, err := client.LoginContext(ctx, user, passwd)
+	if err != nil {
+		fmt.Println("失败")
+		fmt.Printf("✗ 登录失败: %v\n", err)
+		fmt.Println("请稍后运行 hayfrp user login 手动登录")
+		return
+	}
+	fmt.Println("成功")
+
+	session, err := saveLogin(user, passwd, resp.Token, remember)
+	if err != nil {
+		fmt.Printf("✗ 登录成功，但保存登录状态失败: %v\n", err)
+		return
+	}
+	fmt.Printf("✓ 已保存登录状态: %s (Token 存储: %s)\n", sessionFilePath(), session.Store)
+}
+
+// saveLogin 为当前账户保存登录成功后的会话，remember 为 true 时同时保存密码用于自动重新登录
+func saveLogin(user, passwd, token string, remember bool) (*SavedSession, error) {
+	session := &SavedSession{
+		CSRF:         token,
+		Username:     user,
+		LoginTime:    time.Now(),
+		LastVerified: time.Now(),
+		Endpoint:     api.GetCurrentEndpoint(),
+	}
+	if remember {
+		session.AutoRelogin = true
+		session.Password = passwd
+	}
+	if err := saveSession(currentProfile(), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// errInputClosed 标准输入已关闭，无法继续交互
+var errInputClosed = errors.New("输入已结束")
+
+// promptInput 提示输入并校验，校验失败时提示原因并重新输入，标准输入关闭时返回 errInputClosed
+func promptInput(reader *bufio.Reader, label string, validate func(string) error) (string, error) {
+	for {
+		fmt.Printf("%s: ", label)
+		line, err := reader.ReadString('\n')
+		value := strings.TrimSpace(line)
+		if err != nil && value == "" {
+			fmt.Println()
+			if errors.Is(err, io.EOF) {
+				return "", errInputClosed
+			}
+			return "", err
+		}
+		if err := validate(value); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// promptYes 询问是否继续，只有输入 y 时返回 true
+func promptYes(reader *bufio.Reader, question string) bool {
+	fmt.Printf("%s (y/N): ", question)
+	answer, _ := reader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
+
+// promptNewPassword 读取两次新密码，长度不足或两次输入不一致时重新输入
+func promptNewPassword() (string, error) {
+	for {
+		passwd, err := readPasswordWithMask(fmt.Sprintf("密码 (至少%d位)", minPasswordLength))
+		if err != nil {
+			return "", err
+		}
+		if err := validatePassword(passwd); err != nil {
+			fmt.Printf("✗ %v\n", err)
+			continue
+		}
+		confirm, err := readPasswordWithMask("确认密码")
+		if err != nil {
+			return "", err
+		}
+		if confirm != passwd {
+			fmt.Println("✗ 两次输入的密码不一致")
+			continue
+		}
+		return passwd, nil
+	}
+}
+
+// deviceName 返回注册时使用的设备名称，优先使用主机名
+func deviceName() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "hayfrp-cli"
+}
+
+func validateRequired(s string) error {
+	if s == "" {
+		return errors.New("不能为空")
+	}
+	return nil
+}
+
+func validateUsername(s string) error {
+	if !usernamePattern.MatchString(s) {
+		return errors.New("用户名应为3-20位字母、数字或下划线")
+	}
+	return nil
+}
+
+func validateEmail(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s || !strings.Contains(s[strings.LastIndex(s, "@")+1:], ".") {
+		return errors.New("邮箱格式不正确")
+	}
+	return nil
+}
+
+// validateAccount 校验用户名或邮箱
+func validateAccount(s string) error {
+	if strings.Contains(s, "@") {
+		return validateEmail(s)
+	}
+	return validateRequired(s)
+}
+
+func validateCode(s string) error {
+	if !codePattern.MatchString(s) {
+		return errors.New("验证码格式不正确")
+	}
+	return nil
+}
+
+func validatePassword(s string) error {
+	if len([]rune(s)) < minPasswordLength {
+		return fmt.Errorf("密码至少%d位", minPasswordLength)
+	}
+	if strings.TrimSpace(s) != s {
+		return errors.New("密码首尾不能包含空格")
+	}
+	return nil
+}
+
+func init() {
+	userCmd.AddCommand(registerCmd)
+	userCmd.AddCommand(resetPasswordCmd)
+
+	registerCmd.Flags().Bool("remember", false, "保存密码，登录过期后自动重新登录")
+	registerCmd.Flags().String("user", "", "用户名，与 --email、--code 一起使用时不进入向导")
+	registerCmd.Flags().String("email", "", "邮箱")
+	registerCmd.Flags().String("code", "", "邮箱验证码 (通过 hayfrp user send-reg 发送)")
+	registerCmd.Flags().String("password-file", "", "从文件读取密码")
+
+	resetPasswordCmd.Flags().Bool("remember", false, "保存密码，登录过期后自动重新登录")
+	resetPasswordCmd.Flags().String("reset-token", "", "邮件中的重置Token (通过 hayfrp user send-findpass 发送)，指定时不进入向导")
+	resetPasswordCmd.Flags().String("user", "", "用户名，重置成功后自动登录")
+	resetPasswordCmd.Flags().String("password-file", "", "从文件读取新密码")
+}
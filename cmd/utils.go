@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
 	"syscall"
 
 	"golang.org/x/term"
+	"golang.org/x/text/width"
 )
 
 // readPasswordWithMask 读取密码，输入时不显示
@@ -18,3 +24,55 @@ func readPasswordWithMask(prompt string) (string, error) {
 	fmt.Println() // 换行
 	return string(bytePassword), nil
 }
+
+// interruptContext 返回在收到 Ctrl+C 或 SIGTERM 时取消的 Context
+//
+// 调用返回的 stop 后恢复信号的默认行为。
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// yesNo 将布尔值显示为“是”或“否”
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+// displayWidth 返回字符串在终端中占用的列数，中文等全角字符占两列
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// printTable 按列对齐输出表格，第一行为表头
+func printTable(w io.Writer, rows [][]string) {
+	widths := make([]int, 0)
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
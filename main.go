@@ -5,6 +5,6 @@ import (
 )
 
 func main() {
-	// 直接执行start命令
-	cmd.ExecuteStart()
+	// 无参数时进入交互式启动流程，否则分发到对应子命令
+	cmd.Execute()
 }
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess 让子进程使用独立的进程组
+//
+// 终端中按下 Ctrl+C 时信号只发给启动器，由启动器决定何时停止子进程，
+// 避免子进程在启动器写入文件等操作中途被结束。
+func configureProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// interrupt 向子进程发送 SIGINT
+func interrupt(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
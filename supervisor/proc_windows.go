@@ -0,0 +1,16 @@
+//go:build windows
+
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// configureProcess Windows 下子进程与启动器共用控制台，Ctrl+C 会同时发给两者
+func configureProcess(cmd *exec.Cmd) {}
+
+// interrupt Windows 不支持向其他进程发送中断信号，直接结束子进程
+func interrupt(p *os.Process) error {
+	return p.Kill()
+}
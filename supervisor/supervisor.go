@@ -0,0 +1,350 @@
+// Package supervisor 以托管子进程的方式运行 frpc
+//
+// 子进程意外退出后按指数退避自动重启；短时间内反复崩溃或重启次数超过上限时
+// 停止重启并返回错误。Context 被取消时先向子进程发送中断信号，等待其退出，
+// 超时后强制结束。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"hayfrp-cli/backoff"
+)
+
+// DefaultStopTimeout 发送中断信号后等待子进程退出的默认时长
+const DefaultStopTimeout = 5 * time.Second
+
+var (
+	// ErrCrashLoop 子进程在 Policy.CrashLoopWindow 内反复崩溃
+	ErrCrashLoop = errors.New("进程反复崩溃，已停止重启")
+	// ErrMaxRestarts 重启次数超过 Policy.MaxRestarts
+	ErrMaxRestarts = errors.New("超过最大重启次数，已停止重启")
+)
+
+// Policy 子进程退出后的重启策略
+type Policy struct {
+	MaxRestarts     int           // 最大重启次数，0 表示不重启，负数表示不限
+	BaseDelay       time.Duration // 第一次重启前的等待时间，之后每次翻倍
+	MaxDelay        time.Duration // 等待时间上限
+	Jitter          float64       // 等待时间的随机抖动比例，0~1
+	StableAfter     time.Duration // 运行超过该时长视为恢复正常，重置退避等待和崩溃计数
+	CrashLoopCount  int           // CrashLoopWindow 内崩溃达到该次数时判定为崩溃循环，0 表示不检测
+	CrashLoopWindow time.Duration // 崩溃循环的检测窗口
+}
+
+// DefaultPolicy 默认重启策略：不限重启次数，1 分钟内崩溃 5 次视为崩溃循环
+var DefaultPolicy = Policy{
+	MaxRestarts:     -1,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	Jitter:          0.2,
+	StableAfter:     time.Minute,
+	CrashLoopCount:  5,
+	CrashLoopWindow: time.Minute,
+}
+
+// NoRestart 子进程退出后不重启
+var NoRestart = Policy{MaxRestarts: 0}
+
+// backoff 连续第 attempt 次崩溃后的等待时间
+func (p Policy) backoff(attempt int) time.Duration {
+	return backoff.Exponential(p.BaseDelay, p.MaxDelay, p.Jitter, attempt)
+}
+
+// State 子进程的运行状态
+type State string
+
+const (
+	StateIdle     State = "idle"     // 尚未启动
+	StateRunning  State = "running"  // 运行中
+	StateBackoff  State = "backoff"  // 已退出，等待重启
+	StateStopped  State = "stopped"  // 已按要求停止
+	StateFailed   State = "failed"   // 崩溃后不再重启
+	StateStopping State = "stopping" // 正在停止
+)
+
+// EventType 事件类型
+type EventType int
+
+const (
+	EventStarted    EventType = iota // 子进程已启动
+	EventExited                      // 子进程意外退出
+	EventRestarting                  // 等待 Delay 后重启
+	EventGaveUp                      // 不再重启
+	EventStopped                     // 子进程已按要求停止
+)
+
+// Event 子进程状态变化，通过 WithEventHandler 接收
+type Event struct {
+	Type     EventType
+	PID      int
+	Restarts int           // 已重启的次数
+	Uptime   time.Duration // EventExited：本次运行时长
+	Delay    time.Duration // EventRestarting：重启前的等待时间
+	Err      error         // EventExited：退出原因；EventGaveUp：停止重启的原因
+}
+
+// Status 子进程状态快照
+type Status struct {
+	State     State
+	PID       int       // 运行中的进程号，未运行时为 0
+	Restarts  int       // 已重启的次数
+	StartedAt time.Time // 本次启动时间
+	LastExit  error     // 最近一次退出的原因
+}
+
+// Option 配置项，用于 New
+type Option func(*options)
+
+type options struct {
+	policy      Policy
+	dir         string
+	env         []string
+	stdout      io.Writer
+	stderr      io.Writer
+	stopTimeout time.Duration
+	logger      *slog.Logger
+	onEvent     func(Event)
+}
+
+// WithPolicy 设置重启策略，默认为 DefaultPolicy
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithDir 设置子进程的工作目录
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithEnv 设置子进程的环境变量，默认继承当前进程
+func WithEnv(env []string) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+// WithOutput 设置子进程的标准输出和标准错误，默认为 os.Stdout 和 os.Stderr
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(o *options) {
+		o.stdout = stdout
+		o.stderr = stderr
+	}
+}
+
+// WithStopTimeout 设置发送中断信号后等待子进程退出的时长，超时后强制结束
+func WithStopTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.stopTimeout = d
+	}
+}
+
+// WithLogger 设置日志记录器，默认不输出日志
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithEventHandler 在子进程启动、退出、重启时调用 fn，fn 在 Run 所在的 goroutine 中同步执行
+func WithEventHandler(fn func(Event)) Option {
+	return func(o *options) {
+		o.onEvent = fn
+	}
+}
+
+// Supervisor 托管运行一个子进程
+type Supervisor struct {
+	path string
+	args []string
+	opts options
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New 创建运行 path args... 的 Supervisor，调用 Run 后启动
+func New(path string, args []string, opts ...Option) *Supervisor {
+	o := options{
+		policy:      DefaultPolicy,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		stopTimeout: DefaultStopTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = slog.New(slog.DiscardHandler)
+	}
+	return &Supervisor{
+		path:   path,
+		args:   args,
+		opts:   o,
+		status: Status{State: StateIdle},
+	}
+}
+
+// Status 返回子进程的状态快照，可在其他 goroutine 中调用
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Supervisor) update(fn func(*Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+func (s *Supervisor) emit(e Event) {
+	if s.opts.onEvent != nil {
+		s.opts.onEvent(e)
+	}
+}
+
+// Run 启动子进程并在其意外退出后按策略重启，直到 ctx 被取消或不再重启
+//
+// ctx 被取消时停止子进程并返回 nil；子进程无法启动时直接返回启动错误；
+// 因崩溃循环或重启次数超限而停止时返回包装了 ErrCrashLoop / ErrMaxRestarts
+// 和最后一次退出原因的错误。
+func (s *Supervisor) Run(ctx context.Context) error {
+	policy := s.opts.policy
+	log := s.opts.logger.With("path", s.path)
+
+	var crashes []time.Time
+	attempt := 0
+	for {
+		started := time.Now()
+		err := s.runOnce(ctx)
+		uptime := time.Since(started)
+
+		if ctx.Err() != nil {
+			s.update(func(st *Status) {
+				st.State = StateStopped
+				st.PID = 0
+			})
+			log.Info("子进程已停止", "uptime", uptime)
+			s.emit(Event{Type: EventStopped, Restarts: s.Status().Restarts, Uptime: uptime})
+			return nil
+		}
+
+		var startErr *startError
+		if errors.As(err, &startErr) {
+			s.update(func(st *Status) {
+				st.State = StateFailed
+				st.LastExit = startErr.err
+			})
+			return startErr.err
+		}
+
+		if err == nil {
+			err = errors.New("进程已退出")
+		}
+		restarts := s.Status().Restarts
+		log.Warn("子进程退出", "uptime", uptime, "restarts", restarts, "error", err)
+		s.update(func(st *Status) {
+			st.PID = 0
+			st.LastExit = err
+		})
+		s.emit(Event{Type: EventExited, Restarts: restarts, Uptime: uptime, Err: err})
+
+		// 稳定运行一段时间后崩溃，重新开始计算退避和崩溃次数
+		if policy.StableAfter > 0 && uptime >= policy.StableAfter {
+			attempt = 0
+			crashes = crashes[:0]
+		}
+		now := time.Now()
+		crashes = append(crashes, now)
+		for len(crashes) > 0 && now.Sub(crashes[0]) > policy.CrashLoopWindow {
+			crashes = crashes[1:]
+		}
+
+		var giveUp error
+		switch {
+		case policy.CrashLoopCount > 0 && len(crashes) >= policy.CrashLoopCount:
+			giveUp = fmt.Errorf("%w (%s 内退出 %d 次): %v", ErrCrashLoop, policy.CrashLoopWindow, len(crashes), err)
+		case policy.MaxRestarts >= 0 && restarts >= policy.MaxRestarts:
+			if policy.MaxRestarts == 0 {
+				giveUp = err
+			} else {
+				giveUp = fmt.Errorf("%w (%d 次): %v", ErrMaxRestarts, policy.MaxRestarts, err)
+			}
+		}
+		if giveUp != nil {
+			log.Error("停止重启子进程", "error", giveUp)
+			s.update(func(st *Status) { st.State = StateFailed })
+			s.emit(Event{Type: EventGaveUp, Restarts: restarts, Err: giveUp})
+			return giveUp
+		}
+
+		attempt++
+		delay := policy.backoff(attempt)
+		log.Info("等待重启子进程", "delay", delay, "attempt", attempt)
+		s.update(func(st *Status) { st.State = StateBackoff })
+		s.emit(Event{Type: EventRestarting, Restarts: restarts, Delay: delay})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.update(func(st *Status) { st.State = StateStopped })
+			s.emit(Event{Type: EventStopped, Restarts: restarts})
+			return nil
+		case <-timer.C:
+		}
+		s.update(func(st *Status) { st.Restarts++ })
+	}
+}
+
+// startError 子进程无法启动，如可执行文件不存在，重启也不会成功
+type startError struct {
+	err error
+}
+
+func (e *startError) Error() string { return e.err.Error() }
+
+// runOnce 启动一次子进程并等待其退出
+func (s *Supervisor) runOnce(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, s.path, s.args...)
+	cmd.Dir = s.opts.dir
+	cmd.Env = s.opts.env
+	cmd.Stdout = s.opts.stdout
+	cmd.Stderr = s.opts.stderr
+	configureProcess(cmd)
+
+	// ctx 取消时先发送中断信号让子进程正常退出，WaitDelay 后仍未退出则强制结束
+	cmd.Cancel = func() error {
+		s.update(func(st *Status) { st.State = StateStopping })
+		return interrupt(cmd.Process)
+	}
+	cmd.WaitDelay = s.opts.stopTimeout
+
+	if err := cmd.Start(); err != nil {
+		return &startError{err: err}
+	}
+
+	pid := cmd.Process.Pid
+	s.update(func(st *Status) {
+		st.State = StateRunning
+		st.PID = pid
+		st.StartedAt = time.Now()
+	})
+	s.opts.logger.Info("子进程已启动", "path", s.path, "pid", pid)
+	s.emit(Event{Type: EventStarted, PID: pid, Restarts: s.Status().Restarts})
+
+	return cmd.Wait()
+}
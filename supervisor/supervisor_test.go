@@ -0,0 +1,256 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// helperEnv 指定 TestHelperProcess 行为的环境变量
+const helperEnv = "SUPERVISOR_TEST_HELPER"
+
+// TestHelperProcess 不是真正的测试，而是被 Supervisor 作为子进程启动的辅助程序
+//
+//	exit  立即以状态码 1 退出
+//	run   运行 50ms 后以状态码 1 退出
+//	wait  等待中断信号后正常退出
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+	switch mode {
+	case "exit":
+		os.Exit(1)
+	case "run":
+		time.Sleep(50 * time.Millisecond)
+		os.Exit(1)
+	case "wait":
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		select {
+		case <-c:
+			os.Exit(0)
+		case <-time.After(10 * time.Second):
+			os.Exit(2)
+		}
+	}
+	os.Exit(3)
+}
+
+// newHelper 创建运行 TestHelperProcess 的 Supervisor，events 记录收到的事件
+func newHelper(mode string, policy Policy, events *[]Event) *Supervisor {
+	return New(os.Args[0], []string{"-test.run=^TestHelperProcess$"},
+		WithPolicy(policy),
+		WithEnv(append(os.Environ(), helperEnv+"="+mode)),
+		WithOutput(io.Discard, io.Discard),
+		WithStopTimeout(time.Second),
+		WithEventHandler(func(e Event) { *events = append(*events, e) }),
+	)
+}
+
+// countEvents 统计指定类型的事件数
+func countEvents(events []Event, typ EventType) int {
+	n := 0
+	for _, e := range events {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+// delays 返回各次重启前的等待时间
+func delays(events []Event) []time.Duration {
+	var d []time.Duration
+	for _, e := range events {
+		if e.Type == EventRestarting {
+			d = append(d, e.Delay)
+		}
+	}
+	return d
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	want := []time.Duration{1, 2, 4, 5, 5}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w*time.Second {
+			t.Errorf("backoff(%d) = %v，期望 %v", i+1, got, w*time.Second)
+		}
+	}
+	if got := p.backoff(100); got != 5*time.Second {
+		t.Errorf("backoff(100) = %v，期望上限 5s", got)
+	}
+
+	p.Jitter = 0.5
+	for range 100 {
+		if d := p.backoff(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("backoff(1) = %v，超出抖动范围", d)
+		}
+	}
+}
+
+func TestMaxRestarts(t *testing.T) {
+	var events []Event
+	policy := Policy{MaxRestarts: 2, BaseDelay: time.Millisecond, MaxDelay: 3 * time.Millisecond}
+	s := newHelper("exit", policy, &events)
+
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrMaxRestarts) {
+		t.Fatalf("Run() = %v，期望 ErrMaxRestarts", err)
+	}
+	if n := countEvents(events, EventStarted); n != 3 {
+		t.Errorf("启动了 %d 次，期望 3", n)
+	}
+	if got := delays(events); len(got) != 2 || got[0] != time.Millisecond || got[1] != 2*time.Millisecond {
+		t.Errorf("重启等待时间 %v，期望 [1ms 2ms]", got)
+	}
+
+	st := s.Status()
+	if st.State != StateFailed || st.Restarts != 2 || st.PID != 0 {
+		t.Errorf("状态 %+v", st)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(st.LastExit, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Errorf("LastExit = %v，期望退出码 1", st.LastExit)
+	}
+}
+
+func TestCrashLoop(t *testing.T) {
+	var events []Event
+	policy := Policy{
+		MaxRestarts:     -1,
+		BaseDelay:       time.Millisecond,
+		MaxDelay:        time.Millisecond,
+		CrashLoopCount:  3,
+		CrashLoopWindow: time.Minute,
+	}
+	s := newHelper("exit", policy, &events)
+
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrCrashLoop) {
+		t.Fatalf("Run() = %v，期望 ErrCrashLoop", err)
+	}
+	if n := countEvents(events, EventExited); n != 3 {
+		t.Errorf("退出了 %d 次，期望 3", n)
+	}
+	if n := countEvents(events, EventGaveUp); n != 1 {
+		t.Errorf("EventGaveUp %d 次，期望 1", n)
+	}
+}
+
+func TestCrashLoopWindow(t *testing.T) {
+	var events []Event
+	// 每次运行 50ms，窗口只有 10ms，崩溃不会在窗口内累积
+	policy := Policy{
+		MaxRestarts:     3,
+		BaseDelay:       time.Millisecond,
+		MaxDelay:        time.Millisecond,
+		CrashLoopCount:  2,
+		CrashLoopWindow: 10 * time.Millisecond,
+	}
+	s := newHelper("run", policy, &events)
+
+	err := s.Run(context.Background())
+	if !errors.Is(err, ErrMaxRestarts) {
+		t.Fatalf("Run() = %v，期望 ErrMaxRestarts 而不是崩溃循环", err)
+	}
+}
+
+func TestStableAfterResetsBackoff(t *testing.T) {
+	var events []Event
+	policy := Policy{
+		MaxRestarts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    time.Second,
+		StableAfter: 10 * time.Millisecond,
+	}
+	s := newHelper("run", policy, &events)
+
+	if err := s.Run(context.Background()); !errors.Is(err, ErrMaxRestarts) {
+		t.Fatalf("Run() = %v，期望 ErrMaxRestarts", err)
+	}
+	for _, d := range delays(events) {
+		if d != time.Millisecond {
+			t.Errorf("稳定运行后退避应重置，重启等待时间 %v", delays(events))
+			break
+		}
+	}
+}
+
+func TestNoRestart(t *testing.T) {
+	var events []Event
+	s := newHelper("exit", NoRestart, &events)
+
+	err := s.Run(context.Background())
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || errors.Is(err, ErrMaxRestarts) {
+		t.Fatalf("Run() = %v，期望直接返回退出原因", err)
+	}
+	if n := countEvents(events, EventStarted); n != 1 {
+		t.Errorf("启动了 %d 次，期望 1", n)
+	}
+}
+
+func TestStartErrorNotRetried(t *testing.T) {
+	var events []Event
+	s := New(filepath.Join(t.TempDir(), "missing"), nil,
+		WithPolicy(DefaultPolicy),
+		WithEventHandler(func(e Event) { events = append(events, e) }),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err == nil || errors.Is(err, ErrCrashLoop) || errors.Is(err, ErrMaxRestarts) {
+			t.Fatalf("Run() = %v，期望启动错误", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("启动失败后不应重试")
+	}
+	if len(events) != 0 {
+		t.Errorf("启动失败时收到事件 %v", events)
+	}
+	if st := s.Status(); st.State != StateFailed || st.Restarts != 0 {
+		t.Errorf("状态 %+v", st)
+	}
+}
+
+func TestStopOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []Event
+	s := New(os.Args[0], []string{"-test.run=^TestHelperProcess$"},
+		WithPolicy(DefaultPolicy),
+		WithEnv(append(os.Environ(), helperEnv+"=wait")),
+		WithOutput(io.Discard, io.Discard),
+		WithStopTimeout(time.Second),
+		WithEventHandler(func(e Event) {
+			events = append(events, e)
+			if e.Type == EventStarted {
+				// 等子进程注册信号处理后再取消
+				time.AfterFunc(200*time.Millisecond, cancel)
+			}
+		}),
+	)
+
+	if err := s.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v，取消后应返回 nil", err)
+	}
+	if st := s.Status(); st.State != StateStopped || st.PID != 0 || st.Restarts != 0 {
+		t.Errorf("状态 %+v", st)
+	}
+	if n := countEvents(events, EventStopped); n != 1 {
+		t.Errorf("EventStopped %d 次，期望 1", n)
+	}
+}